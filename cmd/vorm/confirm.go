@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/console"
+	"github.com/vorzela/vorm/internal/policy"
+)
+
+// addConfirmationFlags registers the flags that answer confirmation prompts
+func addConfirmationFlags(rootCmd *cobra.Command) {
+	flags := rootCmd.PersistentFlags()
+	flags.BoolP("yes", "y", false, "Answer yes to confirmation prompts (also VORM_ASSUME_YES=1)")
+	flags.Bool("force", false, "Alias for --yes")
+	flags.Bool("i-know-this-is-production", false, "Allow --yes to confirm operations in production")
+}
+
+// assumeYes reports whether prompts should be answered without asking
+func assumeYes(cmd *cobra.Command) bool {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return true
+	}
+	if force, _ := cmd.Flags().GetBool("force"); force {
+		return true
+	}
+	yes, _ := strconv.ParseBool(os.Getenv("VORM_ASSUME_YES"))
+	return yes
+}
+
+// confirmOperation asks the user to confirm a destructive operation
+// An empty requiredText asks a y/N question instead of a typed confirmation.
+// With --yes the prompt is skipped, except where the production policy
+// requires confirmation and --i-know-this-is-production is also needed. Without a
+// terminal on stdin it fails instead of prompting
+func confirmOperation(cmd *cobra.Command, cfg *config.Config, operation, requiredText string) (bool, error) {
+	production := policy.New(cfg).RequiresConfirmation()
+
+	if assumeYes(cmd) {
+		if production {
+			if acknowledged, _ := cmd.Flags().GetBool("i-know-this-is-production"); !acknowledged {
+				return false, &commandError{
+					message: fmt.Sprintf("Refusing to confirm '%s' in production with --yes alone", operation),
+					hint:    "Add --i-know-this-is-production to acknowledge the production environment",
+					code:    ExitPermission,
+				}
+			}
+		}
+
+		console.PrintWarning(fmt.Sprintf("Confirmed '%s' without prompting (--yes)", operation))
+		return true, nil
+	}
+
+	if !console.IsInteractive() {
+		return false, &commandError{
+			message: fmt.Sprintf("Cannot confirm '%s': stdin is not a terminal", operation),
+			hint:    "Pass --yes or set VORM_ASSUME_YES=1 to confirm non-interactively",
+			code:    ExitUsage,
+		}
+	}
+
+	if requiredText == "" {
+		return console.ConfirmDestructiveOperation(operation, production), nil
+	}
+	return console.RequireTypedConfirmation(operation, requiredText, production), nil
+}
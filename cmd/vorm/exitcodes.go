@@ -0,0 +1,134 @@
+package main
+
+import (
+	stderrors "errors"
+	"fmt"
+
+	"github.com/vorzela/vorm/internal/console"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// Exit codes returned by vorm
+// They are part of the CLI contract and documented in docs/commands.md
+const (
+	ExitOK          = 0  // Success
+	ExitFailure     = 1  // Unclassified failure
+	ExitUsage       = 2  // Invalid command, flag or argument
+	ExitPending     = 3  // status --exit-code found pending migrations
+	ExitConnection  = 10 // Could not connect to PostgreSQL
+	ExitMigration   = 11 // A migration or SQL statement failed
+	ExitValidation  = 12 // Invalid configuration or migration file
+	ExitFile        = 13 // File system error
+	ExitPermission  = 14 // Operation not permitted (database privileges or production safety)
+	ExitLockTimeout = 15 // Timed out waiting for the migration lock
+	ExitDrift       = 16 // Applied migration files were modified since they ran
+	ExitInterrupted = 17 // Stopped by SIGINT/SIGTERM or --timeout
+)
+
+// exitCodes maps pkg/errors MigrationError types to exit codes
+var exitCodes = map[errors.ErrorType]int{
+	errors.TypeConnection:  ExitConnection,
+	errors.TypeMigration:   ExitMigration,
+	errors.TypeValidation:  ExitValidation,
+	errors.TypeFile:        ExitFile,
+	errors.TypePermission:  ExitPermission,
+	errors.TypeLockTimeout: ExitLockTimeout,
+	errors.TypeDrift:       ExitDrift,
+	errors.TypeInterrupted: ExitInterrupted,
+}
+
+// commandError is returned by command handlers
+// It carries the message shown to the user and, optionally, a hint and a
+// fixed exit code; otherwise the code is derived from the wrapped error
+type commandError struct {
+	message string
+	err     error
+	hint    string
+	code    int
+}
+
+func (e *commandError) Error() string {
+	switch {
+	case e.err == nil:
+		return e.message
+	case e.message == "":
+		return e.err.Error()
+	default:
+		return fmt.Sprintf("%s: %v", e.message, e.err)
+	}
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// withHint adds a follow-up suggestion printed below the error
+func (e *commandError) withHint(hint string) *commandError {
+	e.hint = hint
+	return e
+}
+
+// commandFailed wraps err with the message shown to the user
+func commandFailed(message string, err error) *commandError {
+	return &commandError{message: message, err: err}
+}
+
+// exitCode returns the process exit code for err
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var cmdErr *commandError
+	if !stderrors.As(err, &cmdErr) {
+		// Errors not produced by a command handler come from cobra's
+		// argument and flag parsing
+		return ExitUsage
+	}
+
+	if cmdErr.code != 0 {
+		return cmdErr.code
+	}
+
+	var migrationErr *errors.MigrationError
+	if stderrors.As(err, &migrationErr) {
+		if code, ok := exitCodes[migrationErr.Type]; ok {
+			return code
+		}
+	}
+
+	return ExitFailure
+}
+
+// handleError prints err and returns the exit code for it
+// All commands report failures through this single handler
+func handleError(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	console.PrintError(err.Error())
+
+	// PostgreSQL reports detail and hint separately from the message
+	var migrationErr *errors.MigrationError
+	if stderrors.As(err, &migrationErr) {
+		if location := migrationErr.Location; location != nil {
+			console.PrintSourceExcerpt(location.File, location.Line, location.Column, location.Excerpt)
+		}
+		if pgErr := migrationErr.PgError(); pgErr != nil {
+			if pgErr.Detail != "" {
+				console.PrintInfo("Detail: " + pgErr.Detail)
+			}
+			if pgErr.Hint != "" {
+				console.PrintInfo("Hint: " + pgErr.Hint)
+			}
+		}
+	}
+
+	var cmdErr *commandError
+	if stderrors.As(err, &cmdErr) && cmdErr.hint != "" {
+		console.PrintInfo(cmdErr.hint)
+	}
+
+	return exitCode(err)
+}
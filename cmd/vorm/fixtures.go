@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/console"
+	"github.com/vorzela/vorm/internal/database"
+	"github.com/vorzela/vorm/internal/fixtures"
+	"github.com/vorzela/vorm/internal/output"
+	"github.com/vorzela/vorm/internal/policy"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+func fixturesLoadCommand(cmd *cobra.Command, args []string) error {
+	truncate, _ := cmd.Flags().GetBool("truncate")
+
+	renderer, err := newRenderer(cmd)
+	if err != nil {
+		return commandFailed("", err)
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return commandFailed("Failed to load configuration", err)
+	}
+
+	// Without arguments, load every file in the fixtures directory
+	patterns := args
+	if len(patterns) == 0 {
+		patterns = []string{cfg.GetFixturesPath()}
+	}
+	paths, err := fixtures.ExpandPaths(patterns)
+	if err != nil {
+		return commandFailed("Failed to find fixtures", err)
+	}
+	if len(paths) == 0 {
+		console.PrintInfo("No fixture files found")
+		return nil
+	}
+	tables, err := fixtures.ReadFiles(paths)
+	if err != nil {
+		return commandFailed("Failed to read fixtures", err)
+	}
+
+	// Truncating loses data, so it follows the destructive operations policy
+	rules := policy.New(cfg)
+	if truncate && rules.Protected() && cfg.Production.DisableDestructiveOperations {
+		return commandFailed("", errors.NewPermissionError(
+			fmt.Sprintf("fixtures:load --truncate is disabled in %s", cfg.Environment),
+			"This is a safety measure to prevent accidental data loss (production.disable_destructive_operations)",
+		))
+	}
+	if rules.RequiresConfirmation() {
+		operation, requiredText := fmt.Sprintf("Load fixtures into %s", cfg.Environment), ""
+		if truncate {
+			operation, requiredText = fmt.Sprintf("Truncate tables and load fixtures into %s", cfg.Environment), "TRUNCATE"
+		}
+		confirmed, err := confirmOperation(cmd, cfg, operation, requiredText)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			console.PrintInfo("Fixture loading cancelled")
+			return nil
+		}
+	}
+
+	// Connect to database
+	ctx := cmd.Context()
+	conn := database.NewConnection(cfg)
+	if err := conn.Connect(ctx); err != nil {
+		return commandFailed("Failed to connect to database", err)
+	}
+	defer conn.Close(ctx)
+
+	console.PrintInfo(fmt.Sprintf("Loading %d fixture files...", len(paths)))
+	results, err := fixtures.NewLoader(conn.Conn(), fixtures.Options{Truncate: truncate}).Load(ctx, tables)
+	if err != nil {
+		return commandFailed("Failed to load fixtures", err)
+	}
+
+	table := output.Table{Headers: []string{"Table", "Rows", "Source"}}
+	for _, result := range results {
+		table.AddRow(result.Table, fmt.Sprintf("%d", result.Rows), result.Source)
+	}
+
+	if results == nil {
+		results = []fixtures.Result{}
+	}
+	return renderResult(renderer, "=== Loaded Fixtures ===", "No fixtures loaded", results, table)
+}
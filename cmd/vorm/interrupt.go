@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/vorzela/vorm/internal/console"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// commandContext is the context of the running command, cancelled on
+// SIGINT or SIGTERM and when --timeout expires
+var commandContext = context.Background()
+
+// cancelTimeout releases the --timeout deadline once the command has finished
+var cancelTimeout context.CancelFunc = func() {}
+
+// addTimeoutFlag adds the global --timeout flag
+func addTimeoutFlag(rootCmd *cobra.Command) {
+	rootCmd.PersistentFlags().Duration("timeout", 0,
+		"Stop the command after this long, e.g. 10m; 0 for no limit")
+}
+
+// signalContext returns a context cancelled by the first SIGINT or SIGTERM
+// The running statement is then cancelled on the server and no further
+// migration starts. A second signal kills the process as usual
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			console.PrintWarning(fmt.Sprintf("Received %s, stopping after cancelling the running statement (repeat to quit immediately)", sig))
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// applyTimeout sets the --timeout deadline on the command's context
+func applyTimeout(cmd *cobra.Command) error {
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if timeout < 0 {
+		return &commandError{message: "--timeout must be 0 or greater", code: ExitUsage}
+	}
+
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+		cmd.SetContext(ctx)
+		cancelTimeout = cancel
+	}
+	commandContext = cmd.Context()
+	return nil
+}
+
+// interruptedCommand reports a failure caused by cancelling the command as
+// interrupted, whatever it was doing at the time
+func interruptedCommand(err error) error {
+	if err == nil || commandContext.Err() == nil || stderrors.Is(err, errors.ErrInterrupted) {
+		return err
+	}
+
+	reason := "cancelled"
+	if stderrors.Is(commandContext.Err(), context.DeadlineExceeded) {
+		reason = "--timeout exceeded"
+	}
+	return &commandError{message: "Interrupted (" + reason + ")", err: err, code: ExitInterrupted}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/console"
+	"github.com/vorzela/vorm/internal/metrics"
+	"github.com/vorzela/vorm/internal/migration"
+)
+
+// metricsRecorder collects the metrics written by --metrics-textfile
+// It stays nil unless a command that migrates or checks status set it up
+var metricsRecorder *metrics.Recorder
+
+// addMetricsFlags adds the global metrics flags
+func addMetricsFlags(rootCmd *cobra.Command) {
+	rootCmd.PersistentFlags().String("metrics-textfile", "",
+		"Write Prometheus metrics to this file for node_exporter's textfile collector")
+}
+
+// observeMetrics records the runs of manager when --metrics-textfile is set
+func observeMetrics(cmd *cobra.Command, cfg *config.Config, manager *migration.Manager) {
+	if path, _ := cmd.Flags().GetString("metrics-textfile"); path == "" {
+		return
+	}
+
+	if metricsRecorder == nil {
+		metricsRecorder = metrics.NewRecorder()
+	}
+	manager.AddObserver(metricsRecorder.Observer(cfg.Environment, cfg.Database.Database))
+}
+
+// recordPending sets the pending migrations gauge from a status check
+func recordPending(cfg *config.Config, pending int) {
+	if metricsRecorder != nil {
+		metricsRecorder.SetPending(cfg.Environment, cfg.Database.Database, pending)
+	}
+}
+
+// writeMetrics writes the metrics textfile once the command has finished,
+// whether or not it succeeded, so failures show up on dashboards too
+func writeMetrics(rootCmd *cobra.Command) {
+	path, _ := rootCmd.PersistentFlags().GetString("metrics-textfile")
+	if path == "" || metricsRecorder == nil {
+		return
+	}
+
+	if err := metricsRecorder.WriteTextfile(path); err != nil {
+		console.PrintWarning(fmt.Sprintf("Failed to write metrics: %v", err))
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/console"
+	"github.com/vorzela/vorm/internal/doctor"
+	"github.com/vorzela/vorm/internal/migration"
+	"github.com/vorzela/vorm/internal/output"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// newRenderer returns a renderer for the global --output flag
+func newRenderer(cmd *cobra.Command) (*output.Renderer, error) {
+	value, _ := cmd.Flags().GetString("output")
+	format, err := output.ParseFormat(value)
+	if err != nil {
+		return nil, err
+	}
+	return output.NewRenderer(format, os.Stdout), nil
+}
+
+// renderResult writes a command result to stdout
+// The title and empty message only apply to table output; machine-readable
+// formats always emit the data, even when it is an empty list
+func renderResult(renderer *output.Renderer, title, emptyMessage string, data interface{}, table output.Table) error {
+	if !renderer.Format().IsMachineReadable() {
+		console.PrintHighlight(title)
+		if len(table.Rows) == 0 {
+			console.PrintInfo(emptyMessage)
+			return nil
+		}
+	}
+
+	if err := renderer.Render(data, table); err != nil {
+		return commandFailed("Failed to render output", err)
+	}
+	return nil
+}
+
+// redactConfig returns a copy of cfg that is safe to print
+func redactConfig(cfg *config.Config) config.Config {
+	redacted := *cfg
+	if redacted.Database.Password != "" {
+		redacted.Database.Password = "********"
+	}
+	return redacted
+}
+
+// configRows flattens the configuration into key/value rows for csv output
+func configRows(cfg *config.Config) [][]string {
+	redacted := redactConfig(cfg)
+	return [][]string{
+		{"environment", redacted.Environment},
+		{"database.connection", redacted.Database.Connection},
+		{"database.host", redacted.Database.Host},
+		{"database.port", fmt.Sprintf("%d", redacted.Database.Port)},
+		{"database.database", redacted.Database.Database},
+		{"database.username", redacted.Database.Username},
+		{"database.password", redacted.Database.Password},
+		{"database.sslmode", redacted.Database.SSLMode},
+		{"migration.table", redacted.Migration.Table},
+		{"migration.directory", redacted.Migration.Directory},
+		{"migration.seeders_directory", redacted.Migration.SeedersDirectory},
+		{"migration.fixtures_directory", redacted.Migration.FixturesDirectory},
+		{"migration.timezone", redacted.Migration.Timezone},
+		{"migration.lock_timeout", redacted.Migration.LockTimeout.String()},
+		{"logging.enabled", fmt.Sprintf("%t", redacted.Logging.Enabled)},
+		{"logging.directory", redacted.Logging.Directory},
+		{"logging.filename", redacted.Logging.Filename},
+		{"logging.level", redacted.Logging.Level},
+		{"logging.format", redacted.Logging.Format},
+		{"logging.max_size", fmt.Sprintf("%d", redacted.Logging.MaxSize)},
+		{"logging.max_backups", fmt.Sprintf("%d", redacted.Logging.MaxBackups)},
+		{"logging.max_age", fmt.Sprintf("%d", redacted.Logging.MaxAge)},
+		{"logging.compress", fmt.Sprintf("%t", redacted.Logging.Compress)},
+		{"production.environments", strings.Join(redacted.Production.Environments, ",")},
+		{"production.require_confirmation", fmt.Sprintf("%t", redacted.Production.RequireConfirmation)},
+		{"production.disable_destructive_operations", fmt.Sprintf("%t", redacted.Production.DisableDestructiveOperations)},
+		{"production.allowed_commands", strings.Join(redacted.Production.AllowedCommands, ",")},
+		{"production.denied_commands", strings.Join(redacted.Production.DeniedCommands, ",")},
+		{"production.allowed_users", strings.Join(redacted.Production.AllowedUsers, ",")},
+		{"production.allowed_hosts", strings.Join(redacted.Production.AllowedHosts, ",")},
+		{"hooks.before_migrate", hookNames(redacted.Hooks.BeforeMigrate)},
+		{"hooks.after_migrate", hookNames(redacted.Hooks.AfterMigrate)},
+		{"hooks.before_each", hookNames(redacted.Hooks.BeforeEach)},
+		{"hooks.after_each", hookNames(redacted.Hooks.AfterEach)},
+		{"hooks.on_failure", hookNames(redacted.Hooks.OnFailure)},
+		{"tracing.enabled", fmt.Sprintf("%t", redacted.Tracing.Enabled)},
+		{"tracing.exporter", redacted.Tracing.Exporter},
+		{"tracing.endpoint", redacted.Tracing.Endpoint},
+		{"tracing.file", redacted.Tracing.File},
+		{"tracing.service_name", redacted.Tracing.ServiceName},
+	}
+}
+
+// hookNames joins the display names of hooks for a config row
+func hookNames(hooks []config.HookConfig) string {
+	names := make([]string, len(hooks))
+	for i, hook := range hooks {
+		names[i] = hook.DisplayName()
+	}
+	return strings.Join(names, ",")
+}
+
+// auditFilter builds the audit filter from the audit command's flags
+func auditFilter(cmd *cobra.Command) (migration.AuditFilter, error) {
+	var filter migration.AuditFilter
+	var err error
+
+	since, _ := cmd.Flags().GetString("since")
+	if filter.Since, err = parseDateFlag("since", since, false); err != nil {
+		return filter, err
+	}
+
+	until, _ := cmd.Flags().GetString("until")
+	if filter.Until, err = parseDateFlag("until", until, true); err != nil {
+		return filter, err
+	}
+
+	if action, _ := cmd.Flags().GetString("action"); action != "" {
+		if filter.Action, err = migration.ParseAuditAction(action); err != nil {
+			return filter, err
+		}
+	}
+
+	filter.Migration, _ = cmd.Flags().GetString("migration")
+	filter.Limit, _ = cmd.Flags().GetInt("limit")
+	return filter, nil
+}
+
+// parseDateFlag parses a YYYY-MM-DD or RFC 3339 flag value
+// A plain date used as an upper bound includes that whole day
+func parseDateFlag(name, value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, errors.NewValidationError(
+			fmt.Sprintf("Invalid --%s date", name),
+			fmt.Sprintf("expected YYYY-MM-DD or RFC 3339. Got: %s", value))
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// printChecklist prints doctor results as a pass/warn/fail checklist
+func printChecklist(checks []doctor.Check) {
+	console.PrintHighlight("=== VORM Doctor ===")
+
+	counts := make(map[doctor.Status]int)
+	for _, check := range checks {
+		counts[check.Status]++
+		line := fmt.Sprintf("%-20s %s", check.Name, check.Message)
+		switch check.Status {
+		case doctor.Pass:
+			console.ColorSuccess.Fprintf(os.Stdout, "✓ %s\n", line)
+		case doctor.Warn:
+			console.ColorWarning.Fprintf(os.Stdout, "⚠ %s\n", line)
+		case doctor.Fail:
+			console.ColorError.Fprintf(os.Stdout, "✗ %s\n", line)
+		default:
+			fmt.Fprintf(os.Stdout, "- %s\n", line)
+		}
+		if check.Hint != "" && check.Status != doctor.Pass {
+			fmt.Fprintf(os.Stdout, "  %-20s → %s\n", "", check.Hint)
+		}
+	}
+
+	fmt.Fprintf(os.Stdout, "\n%d passed, %d warnings, %d failed, %d skipped\n",
+		counts[doctor.Pass], counts[doctor.Warn], counts[doctor.Fail], counts[doctor.Skip])
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/policy"
+)
+
+// enforcePolicy runs before every command and refuses the ones the
+// environment policy forbids
+func enforcePolicy(cmd *cobra.Command, args []string) error {
+	// Help and shell completion never touch the database
+	name := commandName(cmd)
+	first, _, _ := strings.Cut(name, " ")
+	switch first {
+	case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		// Commands report configuration errors themselves
+		return nil
+	}
+
+	if err := policy.New(cfg).Check(name); err != nil {
+		return commandFailed("", err)
+	}
+	return nil
+}
+
+// commandName returns the command path without the binary name, e.g. "db drop"
+func commandName(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
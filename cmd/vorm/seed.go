@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/console"
+	"github.com/vorzela/vorm/internal/logger"
+	"github.com/vorzela/vorm/internal/migration"
+	"github.com/vorzela/vorm/internal/policy"
+)
+
+// addSeedFlag adds --seed to a command that runs migrations
+func addSeedFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("seed", false, "Run the seeders afterwards")
+}
+
+// seedRequested reports whether --seed was given, checking db:seed against
+// the environment policy and confirming it in production before the command
+// changes anything
+func seedRequested(cmd *cobra.Command, cfg *config.Config) (bool, error) {
+	if seed, _ := cmd.Flags().GetBool("seed"); !seed {
+		return false, nil
+	}
+
+	if err := policy.New(cfg).Check("db:seed"); err != nil {
+		return false, commandFailed("", err)
+	}
+
+	confirmed, err := confirmSeeding(cmd, cfg)
+	if err == nil && !confirmed {
+		console.PrintInfo("Seeding cancelled, the seeders won't run")
+	}
+	return confirmed, err
+}
+
+// confirmSeeding asks before seeding an environment protected by the
+// production policy
+func confirmSeeding(cmd *cobra.Command, cfg *config.Config) (bool, error) {
+	if !policy.New(cfg).RequiresConfirmation() {
+		return true, nil
+	}
+	return confirmOperation(cmd, cfg, fmt.Sprintf("Run seeders in %s", cfg.Environment), "")
+}
+
+// runSeeders runs the seeders named, or every seeder enabled in the
+// environment if names is empty
+func runSeeders(cmd *cobra.Command, manager *migration.Manager, names []string) error {
+	console.PrintInfo("Running seeders...")
+	if err := manager.RunSeeders(cmd.Context(), names); err != nil {
+		return commandFailed("Seeding failed", err)
+	}
+
+	console.PrintSuccess("Seeding completed successfully")
+	return nil
+}
+
+func makeSeederCommand(cmd *cobra.Command, args []string) error {
+	console.PrintInfo(fmt.Sprintf("Creating seeder: %s", args[0]))
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return commandFailed("Failed to load configuration", err)
+	}
+
+	// Generate seeder
+	seeder, err := migration.NewGenerator(cfg).GenerateSeeder(args[0])
+	if err != nil {
+		return commandFailed("Failed to create seeder", err)
+	}
+
+	console.PrintSuccess(fmt.Sprintf("Created seeder: %s", seeder.Filename))
+	console.PrintInfo(fmt.Sprintf("Seeder file: %s", seeder.Filepath))
+
+	return nil
+}
+
+func dbSeedCommand(cmd *cobra.Command, args []string) error {
+	classes, _ := cmd.Flags().GetStringSlice("class")
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return commandFailed("Failed to load configuration", err)
+	}
+
+	confirmed, err := confirmSeeding(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		console.PrintInfo("Seeding cancelled")
+		return nil
+	}
+
+	// Create logger
+	log, err := logger.NewLogger(cfg)
+	if err != nil {
+		return commandFailed("Failed to create logger", err)
+	}
+
+	// Create migration manager
+	manager, err := migration.NewManager(cfg, log)
+	if err != nil {
+		return commandFailed("Failed to create migration manager", err)
+	}
+	manager.SetCommand(cmd.Name())
+	traceManager(cfg, manager)
+
+	return runSeeders(cmd, manager, classes)
+}
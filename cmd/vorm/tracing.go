@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/console"
+	"github.com/vorzela/vorm/internal/migration"
+	"github.com/vorzela/vorm/internal/tracing"
+	"go.opentelemetry.io/otel"
+)
+
+// tracingShutdownTimeout bounds the wait for spans to be exported on exit,
+// so an unreachable collector doesn't hold up the command
+const tracingShutdownTimeout = 5 * time.Second
+
+// tracerProvider exports the spans of the command when tracing is enabled
+var tracerProvider *tracing.Provider
+
+// traceManager exports the spans of manager's runs when tracing is enabled
+// A broken exporter is reported but never fails the command
+func traceManager(cfg *config.Config, manager *migration.Manager) {
+	if !cfg.Tracing.Enabled {
+		return
+	}
+
+	if tracerProvider == nil {
+		provider, err := tracing.NewProvider(context.Background(), cfg, os.Stderr)
+		if err != nil {
+			console.PrintWarning(fmt.Sprintf("Tracing disabled: %v", err))
+			return
+		}
+		tracerProvider = provider
+
+		// Batched spans are exported in the background, which reports
+		// failures to the global handler rather than to the caller
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+			console.PrintWarning(fmt.Sprintf("Failed to export traces: %v", err))
+		}))
+	}
+	manager.SetTracerProvider(tracerProvider)
+}
+
+// shutdownTracing flushes the spans of the command once it has finished
+func shutdownTracing() {
+	if tracerProvider == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancel()
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		console.PrintWarning(fmt.Sprintf("Failed to export traces: %v", err))
+	}
+}
@@ -0,0 +1,32 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+)
+
+// Version and commit of the running vorm build
+// The CLI sets them from its -ldflags values; library users get the module
+// version recorded by the Go toolchain
+var (
+	Version = moduleVersion()
+	Commit  = "none"
+)
+
+// moduleVersion returns the version of the vorm module in this binary
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+
+	const module = "github.com/vorzela/vorm"
+	if info.Main.Path == module && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == module {
+			return dep.Version
+		}
+	}
+	return "dev"
+}
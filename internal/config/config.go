@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -15,39 +16,111 @@ import (
 
 // Config represents the complete VORM configuration
 type Config struct {
-	Database    DatabaseConfig  `yaml:"database" mapstructure:"database"`
-	Migration   MigrationConfig `yaml:"migration" mapstructure:"migration"`
-	Logging     LoggingConfig   `yaml:"logging" mapstructure:"logging"`
-	Environment string          `yaml:"environment" mapstructure:"environment"`
+	Database    DatabaseConfig   `yaml:"database" json:"database" mapstructure:"database"`
+	Migration   MigrationConfig  `yaml:"migration" json:"migration" mapstructure:"migration"`
+	Logging     LoggingConfig    `yaml:"logging" json:"logging" mapstructure:"logging"`
+	Environment string           `yaml:"environment" json:"environment" mapstructure:"environment"`
+	Production  ProductionConfig `yaml:"production" json:"production" mapstructure:"production"`
+	Hooks       HooksConfig      `yaml:"hooks" json:"hooks" mapstructure:"hooks"`
+	Tracing     TracingConfig    `yaml:"tracing" json:"tracing" mapstructure:"tracing"`
 }
 
 // DatabaseConfig holds database connection settings
 type DatabaseConfig struct {
-	Connection string `yaml:"connection" mapstructure:"connection"`
-	Host       string `yaml:"host" mapstructure:"host"`
-	Port       int    `yaml:"port" mapstructure:"port"`
-	Database   string `yaml:"database" mapstructure:"database"`
-	Username   string `yaml:"username" mapstructure:"username"`
-	Password   string `yaml:"password" mapstructure:"password"`
-	SSLMode    string `yaml:"sslmode" mapstructure:"sslmode"`
+	Connection string `yaml:"connection" json:"connection" mapstructure:"connection"`
+	Host       string `yaml:"host" json:"host" mapstructure:"host"`
+	Port       int    `yaml:"port" json:"port" mapstructure:"port"`
+	Database   string `yaml:"database" json:"database" mapstructure:"database"`
+	Username   string `yaml:"username" json:"username" mapstructure:"username"`
+	Password   string `yaml:"password" json:"password" mapstructure:"password"`
+	SSLMode    string `yaml:"sslmode" json:"sslmode" mapstructure:"sslmode"`
 }
 
 // MigrationConfig holds migration-specific settings
 type MigrationConfig struct {
-	Table     string `yaml:"table" mapstructure:"table"`
-	Directory string `yaml:"directory" mapstructure:"directory"`
-	Timezone  string `yaml:"timezone" mapstructure:"timezone"`
+	Table             string        `yaml:"table" json:"table" mapstructure:"table"`
+	Directory         string        `yaml:"directory" json:"directory" mapstructure:"directory"`
+	SeedersDirectory  string        `yaml:"seeders_directory" json:"seeders_directory" mapstructure:"seeders_directory"`
+	FixturesDirectory string        `yaml:"fixtures_directory" json:"fixtures_directory" mapstructure:"fixtures_directory"`
+	Timezone          string        `yaml:"timezone" json:"timezone" mapstructure:"timezone"`
+	LockTimeout       time.Duration `yaml:"lock_timeout" json:"lock_timeout" mapstructure:"lock_timeout"` // 0 waits indefinitely
 }
 
 // LoggingConfig holds logging settings
 type LoggingConfig struct {
-	Enabled    bool   `yaml:"enabled" mapstructure:"enabled"`
-	Directory  string `yaml:"directory" mapstructure:"directory"`
-	Filename   string `yaml:"filename" mapstructure:"filename"`
-	Level      string `yaml:"level" mapstructure:"level"`
-	MaxSize    int    `yaml:"max_size" mapstructure:"max_size"`
-	MaxBackups int    `yaml:"max_backups" mapstructure:"max_backups"`
-	MaxAge     int    `yaml:"max_age" mapstructure:"max_age"`
+	Enabled    bool   `yaml:"enabled" json:"enabled" mapstructure:"enabled"`
+	Directory  string `yaml:"directory" json:"directory" mapstructure:"directory"`
+	Filename   string `yaml:"filename" json:"filename" mapstructure:"filename"`
+	Level      string `yaml:"level" json:"level" mapstructure:"level"`
+	Format     string `yaml:"format" json:"format" mapstructure:"format"` // text or json
+	MaxSize    int    `yaml:"max_size" json:"max_size" mapstructure:"max_size"`
+	MaxBackups int    `yaml:"max_backups" json:"max_backups" mapstructure:"max_backups"`
+	MaxAge     int    `yaml:"max_age" json:"max_age" mapstructure:"max_age"`    // days
+	Compress   bool   `yaml:"compress" json:"compress" mapstructure:"compress"` // gzip rotated files
+}
+
+// ProductionConfig is the policy applied to protected environments
+type ProductionConfig struct {
+	Environments                 []string `yaml:"environments" json:"environments" mapstructure:"environments"` // Protected environment names
+	RequireConfirmation          bool     `yaml:"require_confirmation" json:"require_confirmation" mapstructure:"require_confirmation"`
+	DisableDestructiveOperations bool     `yaml:"disable_destructive_operations" json:"disable_destructive_operations" mapstructure:"disable_destructive_operations"`
+	AllowedCommands              []string `yaml:"allowed_commands" json:"allowed_commands" mapstructure:"allowed_commands"` // Empty allows every command
+	DeniedCommands               []string `yaml:"denied_commands" json:"denied_commands" mapstructure:"denied_commands"`
+	AllowedUsers                 []string `yaml:"allowed_users" json:"allowed_users" mapstructure:"allowed_users"` // OS users, empty allows anyone
+	AllowedHosts                 []string `yaml:"allowed_hosts" json:"allowed_hosts" mapstructure:"allowed_hosts"` // Hostnames, empty allows any host
+}
+
+// HooksConfig lists the hooks run around migrations and rollbacks
+type HooksConfig struct {
+	BeforeMigrate []HookConfig `yaml:"before_migrate" json:"before_migrate" mapstructure:"before_migrate"`
+	AfterMigrate  []HookConfig `yaml:"after_migrate" json:"after_migrate" mapstructure:"after_migrate"`
+	BeforeEach    []HookConfig `yaml:"before_each" json:"before_each" mapstructure:"before_each"`
+	AfterEach     []HookConfig `yaml:"after_each" json:"after_each" mapstructure:"after_each"`
+	OnFailure     []HookConfig `yaml:"on_failure" json:"on_failure" mapstructure:"on_failure"`
+}
+
+// HookConfig is a single hook; exactly one of SQL and Command is set
+type HookConfig struct {
+	Name            string   `yaml:"name" json:"name" mapstructure:"name"`
+	SQL             string   `yaml:"sql" json:"sql" mapstructure:"sql"`                            // Path to a SQL file
+	Command         string   `yaml:"command" json:"command" mapstructure:"command"`                // Run with sh -c (cmd /C on Windows)
+	Environments    []string `yaml:"environments" json:"environments" mapstructure:"environments"` // Empty runs in every environment
+	Timeout         int      `yaml:"timeout" json:"timeout" mapstructure:"timeout"`                // seconds, 0 for none
+	ContinueOnError bool     `yaml:"continue_on_error" json:"continue_on_error" mapstructure:"continue_on_error"`
+}
+
+// DisplayName returns the hook's name, or its command or SQL file if unnamed
+func (h HookConfig) DisplayName() string {
+	switch {
+	case h.Name != "":
+		return h.Name
+	case h.SQL != "":
+		return h.SQL
+	default:
+		return h.Command
+	}
+}
+
+// EnabledIn returns true if the hook runs in environment
+func (h HookConfig) EnabledIn(environment string) bool {
+	if len(h.Environments) == 0 {
+		return true
+	}
+	for _, env := range h.Environments {
+		if strings.EqualFold(env, environment) {
+			return true
+		}
+	}
+	return false
+}
+
+// TracingConfig holds OpenTelemetry tracing settings
+type TracingConfig struct {
+	Enabled     bool   `yaml:"enabled" json:"enabled" mapstructure:"enabled"`
+	Exporter    string `yaml:"exporter" json:"exporter" mapstructure:"exporter"`             // otlp, stdout or file
+	Endpoint    string `yaml:"endpoint" json:"endpoint" mapstructure:"endpoint"`             // OTLP/HTTP URL, empty uses OTEL_EXPORTER_OTLP_ENDPOINT
+	File        string `yaml:"file" json:"file" mapstructure:"file"`                         // Spans are appended as JSON lines
+	ServiceName string `yaml:"service_name" json:"service_name" mapstructure:"service_name"` // service.name resource attribute
 }
 
 // Load loads configuration from config files and environment variables
@@ -55,7 +128,7 @@ func Load() (*Config, error) {
 	// Load .env file if it exists
 	if _, err := os.Stat(".env"); err == nil {
 		if err := godotenv.Load(); err != nil {
-			return nil, errors.NewValidationError("Failed to load .env file", err.Error())
+			return nil, errors.NewValidationError("Failed to load .env file", err.Error()).WithCause(err)
 		}
 	}
 
@@ -75,14 +148,14 @@ func Load() (*Config, error) {
 	// Read config file
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, errors.NewValidationError("Failed to read config file", err.Error())
+			return nil, errors.NewValidationError("Failed to read config file", err.Error()).WithCause(err)
 		}
 	}
 
 	// Unmarshal config
 	var config Config
 	if err := viper.Unmarshal(&config); err != nil {
-		return nil, errors.NewValidationError("Failed to unmarshal config", err.Error())
+		return nil, errors.NewValidationError("Failed to unmarshal config", err.Error()).WithCause(err)
 	}
 
 	// Override with environment variables
@@ -102,19 +175,34 @@ func setDefaults() {
 	// Migration defaults
 	viper.SetDefault("migration.table", "schema_migrations")
 	viper.SetDefault("migration.directory", "migrations")
+	viper.SetDefault("migration.seeders_directory", "seeders")
+	viper.SetDefault("migration.fixtures_directory", "fixtures")
 	viper.SetDefault("migration.timezone", "UTC")
+	viper.SetDefault("migration.lock_timeout", "15m")
 
 	// Logging defaults
 	viper.SetDefault("logging.enabled", true)
 	viper.SetDefault("logging.directory", "storage/logs")
 	viper.SetDefault("logging.filename", "vorm.log")
 	viper.SetDefault("logging.level", "info")
+	viper.SetDefault("logging.format", "text")
 	viper.SetDefault("logging.max_size", 100)
 	viper.SetDefault("logging.max_backups", 3)
 	viper.SetDefault("logging.max_age", 30)
+	viper.SetDefault("logging.compress", true)
 
 	// Environment defaults
 	viper.SetDefault("environment", "development")
+
+	// Production policy defaults
+	viper.SetDefault("production.environments", []string{"production", "prod"})
+	viper.SetDefault("production.require_confirmation", true)
+	viper.SetDefault("production.disable_destructive_operations", true)
+
+	// Tracing defaults
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.exporter", "otlp")
+	viper.SetDefault("tracing.service_name", "vorm")
 }
 
 // bindEnvVars binds environment variables to viper keys
@@ -127,6 +215,8 @@ func bindEnvVars() {
 	viper.BindEnv("database.sslmode", "VORM_DB_SSLMODE")
 	viper.BindEnv("environment", "VORM_ENVIRONMENT")
 	viper.BindEnv("logging.level", "VORM_LOG_LEVEL")
+	viper.BindEnv("logging.format", "VORM_LOG_FORMAT")
+	viper.BindEnv("tracing.exporter", "VORM_TRACING_EXPORTER")
 
 	// Support for DATABASE_URL override
 	viper.BindEnv("database_url", "DATABASE_URL")
@@ -167,6 +257,14 @@ func overrideWithEnv(config *Config) {
 	if level := os.Getenv("VORM_LOG_LEVEL"); level != "" {
 		config.Logging.Level = level
 	}
+	if format := os.Getenv("VORM_LOG_FORMAT"); format != "" {
+		config.Logging.Format = format
+	}
+	// Choosing an exporter turns tracing on, e.g. for a single CI job
+	if exporter := os.Getenv("VORM_TRACING_EXPORTER"); exporter != "" {
+		config.Tracing.Enabled = true
+		config.Tracing.Exporter = exporter
+	}
 }
 
 // parseInt safely parses a string to int with default fallback
@@ -263,6 +361,45 @@ func (c *Config) GetMigrationsPath() string {
 	return filepath.Join(cwd, c.Migration.Directory)
 }
 
+// GetSeedersPath returns the absolute path to seeders directory
+func (c *Config) GetSeedersPath() string {
+	if filepath.IsAbs(c.Migration.SeedersDirectory) {
+		return c.Migration.SeedersDirectory
+	}
+	cwd, _ := os.Getwd()
+	return filepath.Join(cwd, c.Migration.SeedersDirectory)
+}
+
+// GetFixturesPath returns the absolute path to fixtures directory
+func (c *Config) GetFixturesPath() string {
+	if filepath.IsAbs(c.Migration.FixturesDirectory) {
+		return c.Migration.FixturesDirectory
+	}
+	cwd, _ := os.Getwd()
+	return filepath.Join(cwd, c.Migration.FixturesDirectory)
+}
+
+// IsMigrationsArchive returns true if migrations are read from a tar.gz archive
+func (c *Config) IsMigrationsArchive() bool {
+	dir := c.Migration.Directory
+	return strings.HasSuffix(dir, ".tar.gz") || strings.HasSuffix(dir, ".tgz")
+}
+
+// GetAuditTable returns the name of the append-only audit table
+func (c *Config) GetAuditTable() string {
+	return c.Migration.Table + "_audit"
+}
+
+// GetSeedersTable returns the name of the table recording run-once seeders
+func (c *Config) GetSeedersTable() string {
+	return c.Migration.Table + "_seeders"
+}
+
+// GetMetaTable returns the name of the table versioning vorm's own schema
+func (c *Config) GetMetaTable() string {
+	return c.Migration.Table + "_meta"
+}
+
 // GetLogsPath returns the absolute path to logs directory
 func (c *Config) GetLogsPath() string {
 	if filepath.IsAbs(c.Logging.Directory) {
@@ -272,10 +409,20 @@ func (c *Config) GetLogsPath() string {
 	return filepath.Join(cwd, c.Logging.Directory)
 }
 
-// IsProduction returns true if we're in production environment
+// IsProduction returns true if the environment is protected by the
+// production policy (production.environments, "production" and "prod" by default)
 func (c *Config) IsProduction() bool {
-	env := strings.ToLower(c.Environment)
-	return env == "production" || env == "prod"
+	environments := c.Production.Environments
+	if len(environments) == 0 {
+		environments = []string{"production", "prod"}
+	}
+
+	for _, env := range environments {
+		if strings.EqualFold(c.Environment, env) {
+			return true
+		}
+	}
+	return false
 }
 
 // IsDevelopment returns true if we're in development environment
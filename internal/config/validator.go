@@ -10,7 +10,8 @@ import (
 
 // Validator handles configuration validation
 type Validator struct {
-	config *Config
+	config            *Config
+	skipMigrationsDir bool
 }
 
 // NewValidator creates a new configuration validator
@@ -18,6 +19,12 @@ func NewValidator(config *Config) *Validator {
 	return &Validator{config: config}
 }
 
+// SkipMigrationsDirectory disables the migrations directory check
+// Used when migrations come from an embedded filesystem or archive
+func (v *Validator) SkipMigrationsDirectory() {
+	v.skipMigrationsDir = true
+}
+
 // Validate validates the entire configuration
 func (v *Validator) Validate() error {
 	if err := v.validateDatabase(); err != nil {
@@ -32,6 +39,14 @@ func (v *Validator) Validate() error {
 		return err
 	}
 
+	if err := v.validateHooks(); err != nil {
+		return err
+	}
+
+	if err := v.validateTracing(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -83,16 +98,30 @@ func (v *Validator) validateMigration() error {
 		return errors.NewValidationError("Migration directory is required", "directory field cannot be empty")
 	}
 
-	// Check if migrations directory exists or can be created
 	migrationsPath := v.config.GetMigrationsPath()
-	if err := v.ensureDirectoryExists(migrationsPath); err != nil {
-		return errors.NewValidationError("Migration directory validation failed", err.Error())
+	switch {
+	case v.skipMigrationsDir:
+		// Migrations are supplied by the caller, nothing to check on disk
+	case v.config.IsMigrationsArchive():
+		// Migrations are shipped as a tar.gz archive which must exist
+		if _, err := os.Stat(migrationsPath); err != nil {
+			return errors.NewValidationError("Migration archive validation failed", err.Error()).WithCause(err)
+		}
+	default:
+		// Check if migrations directory exists or can be created
+		if err := v.ensureDirectoryExists(migrationsPath); err != nil {
+			return errors.NewValidationError("Migration directory validation failed", err.Error()).WithCause(err)
+		}
 	}
 
 	if migration.Timezone == "" {
 		return errors.NewValidationError("Migration timezone is required", "timezone field cannot be empty")
 	}
 
+	if migration.LockTimeout < 0 {
+		return errors.NewValidationError("Invalid migration lock timeout", "lock_timeout must be 0 or greater")
+	}
+
 	return nil
 }
 
@@ -112,7 +141,7 @@ func (v *Validator) validateLogging() error {
 		// Check if logs directory exists or can be created
 		logsPath := v.config.GetLogsPath()
 		if err := v.ensureDirectoryExists(logsPath); err != nil {
-			return errors.NewValidationError("Log directory validation failed", err.Error())
+			return errors.NewValidationError("Log directory validation failed", err.Error()).WithCause(err)
 		}
 
 		validLevels := map[string]bool{
@@ -127,6 +156,10 @@ func (v *Validator) validateLogging() error {
 			return errors.NewValidationError("Invalid log level", fmt.Sprintf("level must be one of: debug, info, warning, error, fatal. Got: %s", logging.Level))
 		}
 
+		if logging.Format != "text" && logging.Format != "json" {
+			return errors.NewValidationError("Invalid log format", fmt.Sprintf("format must be one of: text, json. Got: %s", logging.Format))
+		}
+
 		if logging.MaxSize <= 0 {
 			return errors.NewValidationError("Invalid log max size", "max_size must be greater than 0")
 		}
@@ -143,6 +176,65 @@ func (v *Validator) validateLogging() error {
 	return nil
 }
 
+// validateHooks validates hooks configuration
+func (v *Validator) validateHooks() error {
+	hooks := v.config.Hooks
+	events := []struct {
+		name  string
+		hooks []HookConfig
+	}{
+		{"before_migrate", hooks.BeforeMigrate},
+		{"after_migrate", hooks.AfterMigrate},
+		{"before_each", hooks.BeforeEach},
+		{"after_each", hooks.AfterEach},
+		{"on_failure", hooks.OnFailure},
+	}
+
+	for _, event := range events {
+		for i, hook := range event.hooks {
+			if (hook.SQL == "") == (hook.Command == "") {
+				return errors.NewValidationError("Invalid hook",
+					fmt.Sprintf("hooks.%s[%d] must set exactly one of sql or command", event.name, i))
+			}
+
+			if hook.Timeout < 0 {
+				return errors.NewValidationError("Invalid hook timeout",
+					fmt.Sprintf("hooks.%s[%d].timeout must be 0 or greater", event.name, i))
+			}
+
+			if hook.SQL != "" {
+				if _, err := os.Stat(hook.SQL); err != nil {
+					return errors.NewValidationError("Hook SQL file not found",
+						fmt.Sprintf("hooks.%s[%d]: %v", event.name, i, err)).WithCause(err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateTracing validates tracing configuration
+func (v *Validator) validateTracing() error {
+	tracing := v.config.Tracing
+
+	if !tracing.Enabled {
+		return nil
+	}
+
+	switch tracing.Exporter {
+	case "otlp", "stdout":
+	case "file":
+		if tracing.File == "" {
+			return errors.NewValidationError("Trace file is required for the file exporter", "file field cannot be empty")
+		}
+	default:
+		return errors.NewValidationError("Invalid tracing exporter", fmt.Sprintf("exporter must be one of: otlp, stdout, file. Got: %s", tracing.Exporter))
+	}
+
+	return nil
+}
+
 // ensureDirectoryExists checks if directory exists and creates it if it doesn't
 func (v *Validator) ensureDirectoryExists(path string) error {
 	// Check if directory exists
@@ -179,6 +271,11 @@ func (v *Validator) ValidateEnvironment() error {
 		env = "development" // default
 	}
 
+	// Custom protected environments are valid too
+	for _, protected := range v.config.Production.Environments {
+		validEnvs[protected] = true
+	}
+
 	if !validEnvs[env] {
 		return errors.NewValidationError("Invalid environment", fmt.Sprintf("environment must be one of: development, staging, production, testing. Got: %s", env))
 	}
@@ -188,17 +285,19 @@ func (v *Validator) ValidateEnvironment() error {
 
 // ValidateFilePermissions validates that we have necessary file permissions
 func (v *Validator) ValidateFilePermissions() error {
-	// Check migrations directory permissions
+	// Check migrations directory permissions (archives are read-only)
 	migrationsPath := v.config.GetMigrationsPath()
-	if err := v.checkDirectoryPermissions(migrationsPath); err != nil {
-		return errors.NewValidationError("Migration directory permission error", err.Error())
+	if !v.config.IsMigrationsArchive() {
+		if err := v.checkDirectoryPermissions(migrationsPath); err != nil {
+			return errors.NewValidationError("Migration directory permission error", err.Error()).WithCause(err)
+		}
 	}
 
 	// Check logs directory permissions if logging is enabled
 	if v.config.Logging.Enabled {
 		logsPath := v.config.GetLogsPath()
 		if err := v.checkDirectoryPermissions(logsPath); err != nil {
-			return errors.NewValidationError("Log directory permission error", err.Error())
+			return errors.NewValidationError("Log directory permission error", err.Error()).WithCause(err)
 		}
 	}
 
@@ -1,31 +1,83 @@
 package console
 
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Messages receives all console messages and prompts
+// It is stderr so stdout only carries command results, which keeps
+// --output json|yaml|csv clean for scripts
+var Messages io.Writer = os.Stderr
+
 // PrintSuccess prints a success message with checkmark
 func PrintSuccess(message string) {
-	ColorSuccess.Printf("✓ %s\n", message)
+	ColorSuccess.Fprintf(Messages, "✓ %s\n", message)
 }
 
 // PrintError prints an error message with X mark
 func PrintError(message string) {
-	ColorError.Printf("✗ %s\n", message)
+	ColorError.Fprintf(Messages, "✗ %s\n", message)
 }
 
 // PrintWarning prints a warning message with warning symbol
 func PrintWarning(message string) {
-	ColorWarning.Printf("⚠ %s\n", message)
+	ColorWarning.Fprintf(Messages, "⚠ %s\n", message)
 }
 
 // PrintInfo prints an info message with info symbol
 func PrintInfo(message string) {
-	ColorInfo.Printf("ℹ %s\n", message)
+	ColorInfo.Fprintf(Messages, "ℹ %s\n", message)
 }
 
 // PrintDebug prints a debug message
 func PrintDebug(message string) {
-	ColorDebug.Printf("🐛 %s\n", message)
+	ColorDebug.Fprintf(Messages, "🐛 %s\n", message)
 }
 
 // PrintHighlight prints highlighted text
 func PrintHighlight(message string) {
-	ColorHighlight.Printf("%s\n", message)
+	ColorHighlight.Fprintf(Messages, "%s\n", message)
+}
+
+// PrintSourceExcerpt prints a compiler-style excerpt of a source file
+// The excerpt lines end at line; a caret marks column when it is known
+func PrintSourceExcerpt(path string, line, column int, excerpt []string) {
+	location := fmt.Sprintf("%s:%d", path, line)
+	if column > 0 {
+		location = fmt.Sprintf("%s:%d", location, column)
+	}
+
+	width := len(strconv.Itoa(line))
+	gutter := strings.Repeat(" ", width)
+
+	ColorInfo.Fprintf(Messages, "%s--> ", gutter)
+	fmt.Fprintln(Messages, location)
+	ColorInfo.Fprintf(Messages, "%s |\n", gutter)
+
+	first := line - len(excerpt) + 1
+	for i, text := range excerpt {
+		ColorInfo.Fprintf(Messages, "%*d | ", width, first+i)
+		fmt.Fprintln(Messages, text)
+	}
+
+	if column > 0 && len(excerpt) > 0 {
+		// Copy tabs from the source line so the caret stays aligned
+		var padding strings.Builder
+		for i, r := range []rune(excerpt[len(excerpt)-1]) {
+			if i >= column-1 {
+				break
+			}
+			if r == '\t' {
+				padding.WriteRune('\t')
+			} else {
+				padding.WriteRune(' ')
+			}
+		}
+		ColorInfo.Fprintf(Messages, "%s | ", gutter)
+		ColorError.Fprintf(Messages, "%s^\n", padding.String())
+	}
 }
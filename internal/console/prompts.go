@@ -4,23 +4,26 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/mattn/go-isatty"
 )
 
 // ConfirmDestructiveOperation implements the exact warning system as specified
-func ConfirmDestructiveOperation(operation string) bool {
-	if isProduction() {
-		ColorWarning.Printf("⚠ WARNING: You are in PRODUCTION environment!\n")
-		ColorWarning.Printf("⚠ Operation: %s\n", operation)
-		ColorWarning.Printf("⚠ This operation CANNOT be undone!\n")
-		ColorPrompt.Printf("⚠ Type 'YES' to confirm (case-sensitive): ")
+// production asks for the stricter production confirmation
+func ConfirmDestructiveOperation(operation string, production bool) bool {
+	if production {
+		ColorWarning.Fprintf(Messages, "⚠ WARNING: You are in PRODUCTION environment!\n")
+		ColorWarning.Fprintf(Messages, "⚠ Operation: %s\n", operation)
+		ColorWarning.Fprintf(Messages, "⚠ This operation CANNOT be undone!\n")
+		ColorPrompt.Fprintf(Messages, "⚠ Type 'YES' to confirm (case-sensitive): ")
 
 		var input string
 		fmt.Scanln(&input)
 		return input == "YES"
 	}
 
-	ColorWarning.Printf("⚠ Warning: %s cannot be undone.\n", operation)
-	ColorPrompt.Printf("⚠ Continue? (y/N): ")
+	ColorWarning.Fprintf(Messages, "⚠ Warning: %s cannot be undone.\n", operation)
+	ColorPrompt.Fprintf(Messages, "⚠ Continue? (y/N): ")
 
 	var input string
 	fmt.Scanln(&input)
@@ -28,20 +31,21 @@ func ConfirmDestructiveOperation(operation string) bool {
 }
 
 // RequireTypedConfirmation requires exact text input for dangerous operations
-func RequireTypedConfirmation(operation, requiredText string) bool {
-	if isProduction() {
-		ColorWarning.Printf("⚠ WARNING: You are in PRODUCTION environment!\n")
-		ColorWarning.Printf("⚠ Operation: %s\n", operation)
-		ColorWarning.Printf("⚠ This operation CANNOT be undone!\n")
-		ColorPrompt.Printf("⚠ Type 'YES' to confirm (case-sensitive): ")
+// production asks for the stricter production confirmation
+func RequireTypedConfirmation(operation, requiredText string, production bool) bool {
+	if production {
+		ColorWarning.Fprintf(Messages, "⚠ WARNING: You are in PRODUCTION environment!\n")
+		ColorWarning.Fprintf(Messages, "⚠ Operation: %s\n", operation)
+		ColorWarning.Fprintf(Messages, "⚠ This operation CANNOT be undone!\n")
+		ColorPrompt.Fprintf(Messages, "⚠ Type 'YES' to confirm (case-sensitive): ")
 
 		var input string
 		fmt.Scanln(&input)
 		return input == "YES"
 	}
 
-	ColorWarning.Printf("⚠ WARNING: %s cannot be undone!\n", operation)
-	ColorPrompt.Printf("⚠ Type '%s' to confirm: ", requiredText)
+	ColorWarning.Fprintf(Messages, "⚠ WARNING: %s cannot be undone!\n", operation)
+	ColorPrompt.Fprintf(Messages, "⚠ Type '%s' to confirm: ", requiredText)
 
 	var input string
 	fmt.Scanln(&input)
@@ -50,17 +54,14 @@ func RequireTypedConfirmation(operation, requiredText string) bool {
 
 // PromptForInput prompts user for input with colored prompt
 func PromptForInput(prompt string) string {
-	ColorPrompt.Printf("%s: ", prompt)
+	ColorPrompt.Fprintf(Messages, "%s: ", prompt)
 	var input string
 	fmt.Scanln(&input)
 	return input
 }
 
-// isProduction checks if we're in production environment
-func isProduction() bool {
-	env := strings.ToLower(os.Getenv("VORM_ENV"))
-	if env == "" {
-		env = strings.ToLower(os.Getenv("APP_ENV"))
-	}
-	return env == "production" || env == "prod"
+// IsInteractive reports whether stdin is a terminal a user can answer on
+func IsInteractive() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
 }
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// cancelRequestTimeout bounds the wait for the server to accept a cancel request
+const cancelRequestTimeout = 10 * time.Second
+
+// ExecCancellable executes sql on conn and asks the server to cancel it
+// when ctx is done
+// pgx closes the connection when ctx is cancelled mid-query; this keeps it
+// open, so the caller can still roll back, record the outcome and release
+// the migration lock. The statement then fails with SQLSTATE 57014
+func ExecCancellable(ctx context.Context, conn *pgx.Conn, sql string, args ...any) (pgconn.CommandTag, error) {
+	if err := ctx.Err(); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+
+	cancelled := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(cancelled)
+		cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cancelRequestTimeout)
+		defer cancel()
+		conn.PgConn().CancelRequest(cancelCtx)
+	})
+
+	tag, err := conn.Exec(context.WithoutCancel(ctx), sql, args...)
+	if !stop() {
+		// The cancel request is under way; wait for it so that it can't hit
+		// the next statement on the connection
+		<-cancelled
+	}
+	return tag, err
+}
@@ -28,13 +28,13 @@ func NewConnection(cfg *config.Config) *Connection {
 func (c *Connection) Connect(ctx context.Context) error {
 	conn, err := pgx.Connect(ctx, c.config.GetDSN())
 	if err != nil {
-		return errors.NewConnectionError("Failed to connect to database", err.Error())
+		return errors.NewConnectionError("Failed to connect to database", err.Error()).WithCause(err)
 	}
 
 	// Test connection with a simple ping
 	if err := conn.Ping(ctx); err != nil {
 		conn.Close(ctx)
-		return errors.NewConnectionError("Failed to ping database", err.Error())
+		return errors.NewConnectionError("Failed to ping database", err.Error()).WithCause(err)
 	}
 
 	c.conn = conn
@@ -46,13 +46,13 @@ func (c *Connection) Connect(ctx context.Context) error {
 func (c *Connection) ConnectAdmin(ctx context.Context) (*pgx.Conn, error) {
 	conn, err := pgx.Connect(ctx, c.config.GetAdminDSN())
 	if err != nil {
-		return nil, errors.NewConnectionError("Failed to connect to PostgreSQL server", err.Error())
+		return nil, errors.NewConnectionError("Failed to connect to PostgreSQL server", err.Error()).WithCause(err)
 	}
 
 	// Test connection
 	if err := conn.Ping(ctx); err != nil {
 		conn.Close(ctx)
-		return nil, errors.NewConnectionError("Failed to ping PostgreSQL server", err.Error())
+		return nil, errors.NewConnectionError("Failed to ping PostgreSQL server", err.Error()).WithCause(err)
 	}
 
 	return conn, nil
@@ -78,7 +78,7 @@ func (c *Connection) Exec(ctx context.Context, sql string, args ...interface{})
 
 	_, err := c.conn.Exec(ctx, sql, args...)
 	if err != nil {
-		return errors.NewMigrationError("Failed to execute SQL", err.Error(), "")
+		return errors.NewMigrationError("Failed to execute SQL", err.Error(), "").WithCause(err)
 	}
 
 	return nil
@@ -92,7 +92,7 @@ func (c *Connection) Query(ctx context.Context, sql string, args ...interface{})
 
 	rows, err := c.conn.Query(ctx, sql, args...)
 	if err != nil {
-		return nil, errors.NewMigrationError("Failed to execute query", err.Error(), "")
+		return nil, errors.NewMigrationError("Failed to execute query", err.Error(), "").WithCause(err)
 	}
 
 	return rows, nil
@@ -116,7 +116,7 @@ func (c *Connection) Begin(ctx context.Context) (pgx.Tx, error) {
 
 	tx, err := c.conn.Begin(ctx)
 	if err != nil {
-		return nil, errors.NewMigrationError("Failed to begin transaction", err.Error(), "")
+		return nil, errors.NewMigrationError("Failed to begin transaction", err.Error(), "").WithCause(err)
 	}
 
 	return tx, nil
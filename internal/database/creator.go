@@ -26,7 +26,7 @@ func (c *Creator) CreateDatabase(ctx context.Context) error {
 	// Connect to PostgreSQL server (not specific database)
 	conn, err := pgx.Connect(ctx, c.config.GetAdminDSN())
 	if err != nil {
-		return errors.NewConnectionError("Failed to connect to PostgreSQL server", err.Error())
+		return errors.NewConnectionError("Failed to connect to PostgreSQL server", err.Error()).WithCause(err)
 	}
 	defer conn.Close(ctx)
 
@@ -45,7 +45,7 @@ func (c *Creator) CreateDatabase(ctx context.Context) error {
 		pgx.Identifier{c.config.Database.Database}.Sanitize(),
 		pgx.Identifier{c.config.Database.Username}.Sanitize())
 	if _, err := conn.Exec(ctx, sql); err != nil {
-		return errors.NewMigrationError("Failed to create database", err.Error(), "")
+		return errors.NewMigrationError("Failed to create database", err.Error(), "").WithCause(err)
 	}
 
 	// Grant all privileges on the database to the user
@@ -80,7 +80,7 @@ func (c *Creator) CreateDatabase(ctx context.Context) error {
 // DISABLED in production environment for safety
 func (c *Creator) DropDatabase(ctx context.Context) error {
 	// SAFETY: Disable database dropping in production
-	if c.config.IsProduction() {
+	if c.config.IsProduction() && c.config.Production.DisableDestructiveOperations {
 		return errors.NewPermissionError(
 			"Database drop operation is disabled in production",
 			"This is a safety measure to prevent accidental data loss in production environments",
@@ -90,7 +90,7 @@ func (c *Creator) DropDatabase(ctx context.Context) error {
 	// Connect to PostgreSQL server (not specific database)
 	conn, err := pgx.Connect(ctx, c.config.GetAdminDSN())
 	if err != nil {
-		return errors.NewConnectionError("Failed to connect to PostgreSQL server", err.Error())
+		return errors.NewConnectionError("Failed to connect to PostgreSQL server", err.Error()).WithCause(err)
 	}
 	defer conn.Close(ctx)
 
@@ -111,13 +111,13 @@ func (c *Creator) DropDatabase(ctx context.Context) error {
 		WHERE datname = $1 AND pid <> pg_backend_pid()
 	`
 	if _, err := conn.Exec(ctx, terminateSQL, c.config.Database.Database); err != nil {
-		return errors.NewMigrationError("Failed to terminate database connections", err.Error(), "")
+		return errors.NewMigrationError("Failed to terminate database connections", err.Error(), "").WithCause(err)
 	}
 
 	// Drop database
 	sql := fmt.Sprintf("DROP DATABASE %s", pgx.Identifier{c.config.Database.Database}.Sanitize())
 	if _, err := conn.Exec(ctx, sql); err != nil {
-		return errors.NewMigrationError("Failed to drop database", err.Error(), "")
+		return errors.NewMigrationError("Failed to drop database", err.Error(), "").WithCause(err)
 	}
 
 	return nil
@@ -128,7 +128,7 @@ func (c *Creator) DatabaseExists(ctx context.Context) (bool, error) {
 	// Connect to PostgreSQL server
 	conn, err := pgx.Connect(ctx, c.config.GetAdminDSN())
 	if err != nil {
-		return false, errors.NewConnectionError("Failed to connect to PostgreSQL server", err.Error())
+		return false, errors.NewConnectionError("Failed to connect to PostgreSQL server", err.Error()).WithCause(err)
 	}
 	defer conn.Close(ctx)
 
@@ -141,42 +141,11 @@ func (c *Creator) databaseExists(ctx context.Context, conn *pgx.Conn, dbName str
 	sql := "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1)"
 	err := conn.QueryRow(ctx, sql, dbName).Scan(&exists)
 	if err != nil {
-		return false, errors.NewMigrationError("Failed to check database existence", err.Error(), "")
+		return false, errors.NewMigrationError("Failed to check database existence", err.Error(), "").WithCause(err)
 	}
 	return exists, nil
 }
 
-// CreateMigrationsTable creates the schema_migrations table
-func (c *Creator) CreateMigrationsTable(ctx context.Context, conn *Connection) error {
-	sql := fmt.Sprintf(`
-		CREATE TABLE IF NOT EXISTS %s (
-			id BIGSERIAL PRIMARY KEY,
-			migration VARCHAR(255) NOT NULL UNIQUE,
-			batch INTEGER NOT NULL,
-			executed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-			execution_time INTEGER NOT NULL, -- milliseconds
-			checksum VARCHAR(64) NOT NULL    -- SHA256 of migration file
-		)`, pgx.Identifier{c.config.Migration.Table}.Sanitize())
-
-	if err := conn.Exec(ctx, sql); err != nil {
-		return errors.NewMigrationError("Failed to create migrations table", err.Error(), "")
-	}
-
-	// Create performance indexes as specified in AINOTES.md
-	indexSQL := fmt.Sprintf(`
-		CREATE INDEX IF NOT EXISTS idx_%s_batch ON %s(batch);
-		CREATE INDEX IF NOT EXISTS idx_%s_executed_at ON %s(executed_at);
-	`,
-		c.config.Migration.Table, pgx.Identifier{c.config.Migration.Table}.Sanitize(),
-		c.config.Migration.Table, pgx.Identifier{c.config.Migration.Table}.Sanitize())
-
-	if err := conn.Exec(ctx, indexSQL); err != nil {
-		return errors.NewMigrationError("Failed to create migration table indexes", err.Error(), "")
-	}
-
-	return nil
-}
-
 // ResetDatabase drops and recreates the database
 func (c *Creator) ResetDatabase(ctx context.Context) error {
 	// Drop database
@@ -193,7 +162,7 @@ func (c *Creator) GetDatabaseSize(ctx context.Context, conn *Connection) (int64,
 	var size int64
 	err := conn.QueryRow(ctx, "SELECT pg_database_size($1)", c.config.Database.Database).Scan(&size)
 	if err != nil {
-		return 0, errors.NewMigrationError("Failed to get database size", err.Error(), "")
+		return 0, errors.NewMigrationError("Failed to get database size", err.Error(), "").WithCause(err)
 	}
 	return size, nil
 }
@@ -216,19 +185,56 @@ func (c *Creator) ListTables(ctx context.Context, conn *Connection) ([]string, e
 	for rows.Next() {
 		var tableName string
 		if err := rows.Scan(&tableName); err != nil {
-			return nil, errors.NewMigrationError("Failed to scan table name", err.Error(), "")
+			return nil, errors.NewMigrationError("Failed to scan table name", err.Error(), "").WithCause(err)
 		}
 		tables = append(tables, tableName)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, errors.NewMigrationError("Error reading tables", err.Error(), "")
+		return nil, errors.NewMigrationError("Error reading tables", err.Error(), "").WithCause(err)
 	}
 
 	return tables, nil
 }
 
+// ListEnumTypes returns the enum types created in the public schema,
+// leaving out those owned by extensions
+func (c *Creator) ListEnumTypes(ctx context.Context, conn *Connection) ([]string, error) {
+	sql := `
+		SELECT t.typname
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		WHERE n.nspname = 'public' AND t.typtype = 'e'
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_depend d
+				WHERE d.classid = 'pg_type'::regclass AND d.objid = t.oid AND d.deptype = 'e'
+			)
+		ORDER BY t.typname`
+
+	rows, err := conn.Query(ctx, sql)
+	if err != nil {
+		return nil, errors.NewMigrationError("Failed to list enum types", err.Error(), "").WithCause(err)
+	}
+	defer rows.Close()
+
+	var types []string
+	for rows.Next() {
+		var typeName string
+		if err := rows.Scan(&typeName); err != nil {
+			return nil, errors.NewMigrationError("Failed to scan type name", err.Error(), "").WithCause(err)
+		}
+		types = append(types, typeName)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewMigrationError("Error reading enum types", err.Error(), "").WithCause(err)
+	}
+
+	return types, nil
+}
+
 // DropAllTables drops all tables in the database (for fresh command)
+// Enum types go too, since migrations create them alongside their tables
 func (c *Creator) DropAllTables(ctx context.Context, conn *Connection) error {
 	// Get all tables
 	tables, err := c.ListTables(ctx, conn)
@@ -236,8 +242,13 @@ func (c *Creator) DropAllTables(ctx context.Context, conn *Connection) error {
 		return err
 	}
 
-	if len(tables) == 0 {
-		return nil // No tables to drop
+	types, err := c.ListEnumTypes(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if len(tables) == 0 && len(types) == 0 {
+		return nil // Nothing to drop
 	}
 
 	// Start transaction
@@ -249,9 +260,20 @@ func (c *Creator) DropAllTables(ctx context.Context, conn *Connection) error {
 
 	// Drop all tables (CASCADE to handle dependencies)
 	for _, table := range tables {
+		// The audit trail survives fresh
+		if table == c.config.GetAuditTable() {
+			continue
+		}
 		sql := fmt.Sprintf(`DROP TABLE IF EXISTS "%s" CASCADE`, table)
 		if _, err := tx.Exec(ctx, sql); err != nil {
-			return errors.NewMigrationError("Failed to drop table", err.Error(), table)
+			return errors.NewMigrationError("Failed to drop table", err.Error(), table).WithCause(err)
+		}
+	}
+
+	for _, typeName := range types {
+		sql := fmt.Sprintf(`DROP TYPE IF EXISTS "%s" CASCADE`, typeName)
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return errors.NewMigrationError("Failed to drop type", err.Error(), typeName).WithCause(err)
 		}
 	}
 
@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vorzela/vorm/internal/buildinfo"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// SchemaVersion is the version of vorm's own tables this build expects
+// Bump it together with a new entry in schemaSteps
+const SchemaVersion = 4
+
+// schemaStep upgrades vorm's tables by one version
+// Steps must be idempotent: databases created before versioning existed
+// replay every step against tables that may already be in place
+type schemaStep struct {
+	version     int
+	description string
+	sql         func(c *Creator) string
+}
+
+// schemaSteps lists every upgrade in order
+var schemaSteps = []schemaStep{
+	{1, "Create migrations table", (*Creator).migrationsTableSQL},
+	{2, "Add run_id to migrations table", (*Creator).runIDColumnSQL},
+	{3, "Create append-only audit table", (*Creator).auditTableSQL},
+	{4, "Create seeders table", (*Creator).seedersTableSQL},
+}
+
+// SchemaState is the metadata schema version recorded in a database
+type SchemaState struct {
+	Version     int    // Highest applied schema version, 0 for a new database
+	VormVersion string // vorm build that applied it
+}
+
+// UpgradeSchema creates or upgrades vorm's tables to SchemaVersion
+// It refuses to touch a database upgraded by a newer vorm
+func (c *Creator) UpgradeSchema(ctx context.Context, conn *Connection) error {
+	metaTable := pgx.Identifier{c.config.GetMetaTable()}.Sanitize()
+
+	createMeta := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			version INTEGER PRIMARY KEY,
+			description VARCHAR(255) NOT NULL,
+			vorm_version VARCHAR(64),
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`, metaTable)
+	if err := conn.Exec(ctx, createMeta); err != nil {
+		return errors.NewMigrationError("Failed to create schema metadata table", err.Error(), "").WithCause(err)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize concurrent upgrades; readers are not blocked
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`LOCK TABLE %s IN EXCLUSIVE MODE`, metaTable)); err != nil {
+		return errors.NewMigrationError("Failed to lock schema metadata table", err.Error(), "").WithCause(err)
+	}
+
+	state, err := c.schemaState(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if err := checkSchemaVersion(state); err != nil {
+		return err
+	}
+	if state.Version == SchemaVersion {
+		return nil
+	}
+
+	for _, step := range schemaSteps {
+		if step.version <= state.Version {
+			continue
+		}
+
+		if _, err := tx.Exec(ctx, step.sql(c)); err != nil {
+			return errors.NewMigrationError(
+				fmt.Sprintf("Failed to upgrade vorm schema to version %d (%s)", step.version, step.description),
+				err.Error(), "").WithCause(err)
+		}
+
+		record := fmt.Sprintf(`INSERT INTO %s (version, description, vorm_version) VALUES ($1, $2, $3)`, metaTable)
+		if _, err := tx.Exec(ctx, record, step.version, step.description, buildinfo.Version); err != nil {
+			return errors.NewMigrationError("Failed to record schema version", err.Error(), "").WithCause(err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.NewMigrationError("Failed to commit schema upgrade", err.Error(), "").WithCause(err)
+	}
+	return nil
+}
+
+// SchemaState returns the metadata schema version recorded in the database
+func (c *Creator) SchemaState(ctx context.Context, conn *Connection) (*SchemaState, error) {
+	var exists bool
+	err := conn.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`,
+		pgx.Identifier{c.config.GetMetaTable()}.Sanitize()).Scan(&exists)
+	if err != nil {
+		return nil, errors.NewMigrationError("Failed to check schema metadata table", err.Error(), "").WithCause(err)
+	}
+	if !exists {
+		return &SchemaState{}, nil
+	}
+
+	return c.schemaState(ctx, conn.Conn())
+}
+
+// querier is satisfied by both *pgx.Conn and pgx.Tx
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// schemaState reads the highest applied version from the meta table
+func (c *Creator) schemaState(ctx context.Context, q querier) (*SchemaState, error) {
+	sql := fmt.Sprintf(`
+		SELECT version, COALESCE(vorm_version, '')
+		FROM %s
+		ORDER BY version DESC
+		LIMIT 1
+	`, pgx.Identifier{c.config.GetMetaTable()}.Sanitize())
+
+	state := &SchemaState{}
+	err := q.QueryRow(ctx, sql).Scan(&state.Version, &state.VormVersion)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, errors.NewMigrationError("Failed to read schema version", err.Error(), "").WithCause(err)
+	}
+	return state, nil
+}
+
+// checkSchemaVersion refuses databases upgraded by a newer vorm
+func checkSchemaVersion(state *SchemaState) error {
+	if state.Version <= SchemaVersion {
+		return nil
+	}
+	return errors.NewValidationError(
+		"Database was upgraded by a newer version of vorm",
+		fmt.Sprintf("schema version %d was applied by vorm %s; this vorm (%s) supports up to version %d. Upgrade vorm to continue",
+			state.Version, state.VormVersion, buildinfo.Version, SchemaVersion),
+	)
+}
+
+// migrationsTableSQL creates the migrations table and its indexes
+func (c *Creator) migrationsTableSQL() string {
+	table := c.config.Migration.Table
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			migration VARCHAR(255) NOT NULL UNIQUE,
+			batch INTEGER NOT NULL,
+			executed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			execution_time INTEGER NOT NULL, -- milliseconds
+			checksum VARCHAR(64) NOT NULL    -- SHA256 of migration file
+		);
+		CREATE INDEX IF NOT EXISTS %s ON %s(batch);
+		CREATE INDEX IF NOT EXISTS %s ON %s(executed_at);
+	`,
+		pgx.Identifier{table}.Sanitize(),
+		pgx.Identifier{"idx_" + table + "_batch"}.Sanitize(), pgx.Identifier{table}.Sanitize(),
+		pgx.Identifier{"idx_" + table + "_executed_at"}.Sanitize(), pgx.Identifier{table}.Sanitize())
+}
+
+// runIDColumnSQL adds the run that applied each migration
+func (c *Creator) runIDColumnSQL() string {
+	return fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS run_id VARCHAR(36)`,
+		pgx.Identifier{c.config.Migration.Table}.Sanitize())
+}
+
+// auditTableSQL creates the append-only audit table
+// A trigger rejects UPDATE and DELETE so recorded actions can't be rewritten
+func (c *Creator) auditTableSQL() string {
+	table := c.config.GetAuditTable()
+	trigger := pgx.Identifier{table + "_append_only"}.Sanitize()
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			action VARCHAR(32) NOT NULL,    -- up, down, baseline, checksum-accept, repair, fake
+			migration VARCHAR(255) NOT NULL,
+			batch INTEGER,
+			checksum VARCHAR(64),
+			run_id VARCHAR(36),
+			os_user VARCHAR(255),
+			hostname VARCHAR(255),
+			vorm_version VARCHAR(64),
+			vorm_commit VARCHAR(64),
+			environment VARCHAR(64),
+			duration INTEGER NOT NULL,      -- milliseconds
+			outcome VARCHAR(16) NOT NULL,   -- success or failure
+			error TEXT,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS %s ON %s(created_at);
+		CREATE INDEX IF NOT EXISTS %s ON %s(migration);
+
+		CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit table %% is append-only', TG_TABLE_NAME;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS %s ON %s;
+		CREATE TRIGGER %s BEFORE UPDATE OR DELETE ON %s
+			FOR EACH ROW EXECUTE FUNCTION %s();
+	`,
+		pgx.Identifier{table}.Sanitize(),
+		pgx.Identifier{"idx_" + table + "_created_at"}.Sanitize(), pgx.Identifier{table}.Sanitize(),
+		pgx.Identifier{"idx_" + table + "_migration"}.Sanitize(), pgx.Identifier{table}.Sanitize(),
+		trigger,
+		trigger, pgx.Identifier{table}.Sanitize(),
+		trigger, pgx.Identifier{table}.Sanitize(),
+		trigger)
+}
+
+// seedersTableSQL creates the table recording seeders that run only once
+func (c *Creator) seedersTableSQL() string {
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			seeder VARCHAR(255) NOT NULL UNIQUE,
+			executed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			execution_time INTEGER NOT NULL, -- milliseconds
+			checksum VARCHAR(64),            -- SHA256 of seeder file, NULL for Go seeders
+			run_id VARCHAR(36)
+		)
+	`, pgx.Identifier{c.config.GetSeedersTable()}.Sanitize())
+}
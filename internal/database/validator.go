@@ -77,7 +77,7 @@ func (v *Validator) checkCreateTablePermission(ctx context.Context, conn *Connec
 	// Try to create a temporary table
 	sql := `CREATE TEMPORARY TABLE vorm_permission_test (id INTEGER)`
 	if err := conn.Exec(ctx, sql); err != nil {
-		return errors.NewPermissionError("No CREATE TABLE permission", err.Error())
+		return errors.NewPermissionError("No CREATE TABLE permission", err.Error()).WithCause(err)
 	}
 
 	// Clean up
@@ -92,13 +92,13 @@ func (v *Validator) checkCreateIndexPermission(ctx context.Context, conn *Connec
 	// Create a temporary table first
 	sql := `CREATE TEMPORARY TABLE vorm_index_test (id INTEGER)`
 	if err := conn.Exec(ctx, sql); err != nil {
-		return errors.NewPermissionError("Cannot create temporary table for index test", err.Error())
+		return errors.NewPermissionError("Cannot create temporary table for index test", err.Error()).WithCause(err)
 	}
 
 	// Try to create an index
 	sql = `CREATE INDEX vorm_idx_test ON vorm_index_test (id)`
 	if err := conn.Exec(ctx, sql); err != nil {
-		return errors.NewPermissionError("No CREATE INDEX permission", err.Error())
+		return errors.NewPermissionError("No CREATE INDEX permission", err.Error()).WithCause(err)
 	}
 
 	// Clean up
@@ -128,7 +128,7 @@ func (v *Validator) ValidateSchema(ctx context.Context) error {
 	var exists bool
 	err := conn.QueryRow(ctx, sql, v.config.Migration.Table).Scan(&exists)
 	if err != nil {
-		return errors.NewValidationError("Failed to check migration table", err.Error())
+		return errors.NewValidationError("Failed to check migration table", err.Error()).WithCause(err)
 	}
 
 	if !exists {
@@ -140,14 +140,8 @@ func (v *Validator) ValidateSchema(ctx context.Context) error {
 }
 
 // ValidateSchemaStructure validates the migration table structure
-func (v *Validator) ValidateSchemaStructure(ctx context.Context) error {
-	conn := NewConnection(v.config)
-
-	if err := conn.Connect(ctx); err != nil {
-		return err
-	}
-	defer conn.Close(ctx)
-
+// conn must be connected to the application database
+func (v *Validator) ValidateSchemaStructure(ctx context.Context, conn *Connection) error {
 	// Check migration table columns
 	sql := `
 		SELECT column_name, data_type 
@@ -158,7 +152,7 @@ func (v *Validator) ValidateSchemaStructure(ctx context.Context) error {
 
 	rows, err := conn.Query(ctx, sql, v.config.Migration.Table)
 	if err != nil {
-		return errors.NewValidationError("Failed to check migration table structure", err.Error())
+		return errors.NewValidationError("Failed to check migration table structure", err.Error()).WithCause(err)
 	}
 	defer rows.Close()
 
@@ -169,13 +163,14 @@ func (v *Validator) ValidateSchemaStructure(ctx context.Context) error {
 		"executed_at":    "timestamp with time zone",
 		"execution_time": "integer",
 		"checksum":       "character varying",
+		"run_id":         "character varying",
 	}
 
 	foundColumns := make(map[string]string)
 	for rows.Next() {
 		var columnName, dataType string
 		if err := rows.Scan(&columnName, &dataType); err != nil {
-			return errors.NewValidationError("Failed to scan column info", err.Error())
+			return errors.NewValidationError("Failed to scan column info", err.Error()).WithCause(err)
 		}
 		foundColumns[columnName] = dataType
 	}
@@ -184,7 +179,7 @@ func (v *Validator) ValidateSchemaStructure(ctx context.Context) error {
 	for column, expectedType := range expectedColumns {
 		if foundType, exists := foundColumns[column]; !exists {
 			return errors.NewValidationError("Missing migration table column",
-				"Column '"+column+"' not found. Run 'vorm init' to recreate the migration table")
+				"Column '"+column+"' not found. The table may belong to another tool; set migration.table to a different name")
 		} else if foundType != expectedType {
 			return errors.NewValidationError("Invalid migration table column type",
 				"Column '"+column+"' has type '"+foundType+"', expected '"+expectedType+"'")
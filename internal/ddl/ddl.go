@@ -0,0 +1,198 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column describes a column of a generated migration
+// The JSON form checksums builder migrations; omitempty keeps it stable
+// when fields are added
+type Column struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type,omitempty"` // SQL type; enum columns use the type created for them
+	Enum       []string `json:"enum,omitempty"` // Values of an enum column
+	Primary    bool     `json:"primary,omitempty"`
+	NotNull    bool     `json:"not_null,omitempty"`
+	Unique     bool     `json:"unique,omitempty"`
+	Index      bool     `json:"index,omitempty"`      // Index the column on its own
+	Default    string   `json:"default,omitempty"`    // SQL expression
+	References string   `json:"references,omitempty"` // table(column) of a foreign key
+	OnDelete   string   `json:"on_delete,omitempty"`  // ON DELETE action of the foreign key
+}
+
+// Index is an index over one or more columns
+type Index struct {
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+// CreateTable holds the statements creating a table and dropping it again
+type CreateTable struct {
+	Types       []string // CREATE TYPE statements, run before Table
+	Table       string   // CREATE TABLE statement
+	Indexes     []string // CREATE INDEX statements, run after Table
+	DropIndexes []string // In Indexes order
+	Drop        string   // DROP TABLE statement
+	DropTypes   []string // In Types order
+}
+
+// NewCreateTable returns the statements creating table with columns and
+// indexes. A single primary column is declared inline; several form a
+// composite primary key. Column Index flags are ignored, list them in indexes
+func NewCreateTable(table string, columns []Column, indexes []Index) CreateTable {
+	var primary []string
+	for _, column := range columns {
+		if column.Primary {
+			primary = append(primary, column.Name)
+		}
+	}
+
+	var create CreateTable
+	var definitions, constraints []string
+	for _, column := range columns {
+		if column.Enum != nil {
+			createType, dropType := CreateEnum(table, column)
+			create.Types = append(create.Types, createType)
+			create.DropTypes = append(create.DropTypes, dropType)
+		}
+		definitions = append(definitions, Definition(table, column, len(primary) == 1))
+		if column.References != "" {
+			_, constraint := ForeignKey(table, column)
+			constraints = append(constraints, constraint)
+		}
+	}
+	if len(primary) > 1 {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primary, ", ")))
+	}
+	definitions = append(definitions, constraints...)
+	create.Table = fmt.Sprintf("CREATE TABLE %s (\n    %s\n);", table, strings.Join(definitions, ",\n    "))
+
+	for _, index := range indexes {
+		createIndex, dropIndex := CreateIndex(table, index)
+		create.Indexes = append(create.Indexes, createIndex)
+		create.DropIndexes = append(create.DropIndexes, dropIndex)
+	}
+	create.Drop = fmt.Sprintf("DROP TABLE IF EXISTS %s;", table)
+	return create
+}
+
+// AddColumn returns the statements adding column to an existing table with
+// its enum type, foreign key and index, and the statements undoing them in
+// reverse order
+func AddColumn(table string, column Column) (up, down []string) {
+	undo := func(statement string) {
+		down = append([]string{statement}, down...)
+	}
+
+	if column.Enum != nil {
+		createType, dropType := CreateEnum(table, column)
+		up = append(up, createType)
+		undo(dropType)
+	}
+	up = append(up, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", table, Definition(table, column, true)))
+	undo(fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;", table, column.Name))
+	if column.References != "" {
+		name, constraint := ForeignKey(table, column)
+		up = append(up, fmt.Sprintf("ALTER TABLE %s ADD %s;", table, constraint))
+		undo(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", table, name))
+	}
+	if column.Index {
+		createIndex, dropIndex := CreateIndex(table, Index{Columns: []string{column.Name}})
+		up = append(up, createIndex)
+		undo(dropIndex)
+	}
+	return up, down
+}
+
+// Definition returns the column definition; inlinePrimary is false when
+// the table declares a composite primary key instead
+func Definition(table string, column Column, inlinePrimary bool) string {
+	definition := column.Name + " " + column.Type
+	if column.Enum != nil {
+		definition = column.Name + " " + EnumType(table, column.Name)
+	}
+	if column.Primary && inlinePrimary {
+		definition += " PRIMARY KEY"
+	}
+	if column.NotNull {
+		definition += " NOT NULL"
+	}
+	if column.Default != "" {
+		definition += " DEFAULT " + column.Default
+	}
+	if column.Unique {
+		definition += " UNIQUE"
+	}
+	return definition
+}
+
+// ForeignKey returns the name and definition of the column's foreign key
+func ForeignKey(table string, column Column) (name, definition string) {
+	name = ObjectName("fk", table, column.Name)
+	definition = fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s", name, column.Name, column.References)
+	if column.OnDelete != "" {
+		definition += " ON DELETE " + column.OnDelete
+	}
+	return name, definition
+}
+
+// CreateEnum returns the statements creating and dropping an enum column's type
+func CreateEnum(table string, column Column) (up, down string) {
+	labels := make([]string, 0, len(column.Enum))
+	for _, label := range column.Enum {
+		labels = append(labels, "'"+strings.ReplaceAll(label, "'", "''")+"'")
+	}
+	name := EnumType(table, column.Name)
+	return fmt.Sprintf("CREATE TYPE %s AS ENUM (%s);", name, strings.Join(labels, ", ")),
+		fmt.Sprintf("DROP TYPE IF EXISTS %s;", name)
+}
+
+// CreateIndex returns the statements creating and dropping an index named
+// idx_<table>_<columns>, or uq_<table>_<columns> if it is unique
+func CreateIndex(table string, index Index) (up, down string) {
+	prefix, kind := "idx", "INDEX"
+	if index.Unique {
+		prefix, kind = "uq", "UNIQUE INDEX"
+	}
+	name := ObjectName(prefix, table, index.Columns...)
+	return fmt.Sprintf("CREATE %s %s ON %s(%s);", kind, name, table, strings.Join(index.Columns, ", ")),
+		fmt.Sprintf("DROP INDEX IF EXISTS %s;", QualifiedLike(table, name))
+}
+
+// EnumType returns the name of the enum type of a column, <table>_<column>,
+// in the table's schema
+func EnumType(table, column string) string {
+	return table + "_" + column
+}
+
+// ObjectName names an index or constraint after the table and columns
+func ObjectName(prefix, table string, columns ...string) string {
+	return fmt.Sprintf("%s_%s_%s", prefix, strings.ReplaceAll(table, ".", "_"), strings.Join(columns, "_"))
+}
+
+// Unqualified returns name without its schema
+func Unqualified(name string) string {
+	if index := strings.LastIndex(name, "."); index >= 0 {
+		return name[index+1:]
+	}
+	return name
+}
+
+// QualifiedLike returns name in the schema of reference, if it has one
+func QualifiedLike(reference, name string) string {
+	name = Unqualified(name)
+	if index := strings.LastIndex(reference, "."); index >= 0 {
+		return reference[:index+1] + name
+	}
+	return name
+}
+
+// Reversed returns a copy of statements in reverse order
+func Reversed(statements []string) []string {
+	out := make([]string, 0, len(statements))
+	for i := len(statements) - 1; i >= 0; i-- {
+		out = append(out, statements[i])
+	}
+	return out
+}
@@ -0,0 +1,378 @@
+package doctor
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/database"
+	"github.com/vorzela/vorm/internal/logger"
+	"github.com/vorzela/vorm/internal/migration"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// Status is the outcome of a single check
+type Status string
+
+// Check outcomes
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+	Skip Status = "skip" // An earlier failure made the check impossible
+)
+
+// Thresholds for the server checks
+const (
+	minServerVersion  = 120000 // PostgreSQL 12
+	maxClockSkew      = 5 * time.Second
+	maxClockSkewFatal = time.Minute
+)
+
+// Check is the result of one diagnostic
+type Check struct {
+	Name    string `json:"name" yaml:"name"`
+	Status  Status `json:"status" yaml:"status"`
+	Message string `json:"message" yaml:"message"`
+	Hint    string `json:"hint,omitempty" yaml:"hint,omitempty"` // How to fix a warning or failure
+	Err     error  `json:"-" yaml:"-"`
+}
+
+// Doctor runs every configuration, database and migration check
+// The permissions check creates and drops temporary tables; otherwise it
+// only reads from the database, and the schema is never upgraded
+type Doctor struct {
+	config *config.Config
+	logger logger.Logger
+	checks []Check
+}
+
+// New creates a doctor for cfg
+func New(cfg *config.Config, log logger.Logger) *Doctor {
+	return &Doctor{
+		config: cfg,
+		logger: log,
+	}
+}
+
+// Run runs all checks in order and returns their results
+// Checks that depend on a failed check are reported as skipped
+func (d *Doctor) Run(ctx context.Context) []Check {
+	d.checks = nil
+
+	configOK := d.checkConfig()
+	d.checkEnvironment()
+	d.checkFilePermissions()
+
+	files, filesOK := d.checkMigrationFiles()
+
+	dbValidator := database.NewValidator(d.config)
+	if !configOK {
+		d.skip("Database", "Connection", "Server version", "Permissions", "Extensions", "Clock skew", "Migrations table", "Schema version", "Pending migrations", "Missing migrations", "Checksums")
+		return d.checks
+	}
+
+	exists := d.run("Database", func() (Status, string, string, error) {
+		if err := dbValidator.ValidateDatabase(ctx); err != nil {
+			return Fail, fmt.Sprintf("Database %s is not available", d.config.Database.Database), "Run 'vorm db create' or 'vorm setup'", err
+		}
+		return Pass, fmt.Sprintf("Database %s exists", d.config.Database.Database), "", nil
+	})
+	connected := exists && d.run("Connection", func() (Status, string, string, error) {
+		if err := dbValidator.ValidateConnection(ctx); err != nil {
+			return Fail, "Cannot connect to the database", "Check database.host, port, username and password", err
+		}
+		return Pass, fmt.Sprintf("Connected to %s on %s:%d", d.config.Database.Database, d.config.Database.Host, d.config.Database.Port), "", nil
+	})
+	if !connected {
+		if !exists {
+			d.skip("Connection")
+		}
+		d.skip("Server version", "Permissions", "Extensions", "Clock skew", "Migrations table", "Schema version", "Pending migrations", "Missing migrations", "Checksums")
+		return d.checks
+	}
+
+	conn := database.NewConnection(d.config)
+	if err := conn.Connect(ctx); err != nil {
+		d.add(Check{Name: "Connection", Status: Fail, Message: "Connection lost", Err: err})
+		return d.checks
+	}
+	defer conn.Close(ctx)
+
+	d.checkServerVersion(ctx, conn)
+	d.run("Permissions", func() (Status, string, string, error) {
+		if err := dbValidator.ValidatePermissions(ctx); err != nil {
+			return Fail, "Missing CREATE TABLE or CREATE INDEX privilege", "GRANT CREATE ON SCHEMA public TO the migration user", err
+		}
+		return Pass, "Can create tables and indexes", "", nil
+	})
+	d.checkExtensions(ctx, conn)
+	d.checkClockSkew(ctx, conn)
+
+	tableExists := false
+	tableOK := d.run("Migrations table", func() (Status, string, string, error) {
+		if err := dbValidator.ValidateSchema(ctx); err != nil {
+			return Warn, fmt.Sprintf("Table %s does not exist", d.config.Migration.Table), "Run 'vorm setup' or any migrate command to create it", nil
+		}
+		tableExists = true
+		if err := dbValidator.ValidateSchemaStructure(ctx, conn); err != nil {
+			return Fail, "Table has an unexpected structure", "Point migration.table at a table owned by vorm", err
+		}
+		return Pass, fmt.Sprintf("Table %s has the expected columns", d.config.Migration.Table), "", nil
+	})
+	d.checkSchemaVersion(ctx, conn)
+
+	if !tableExists || !tableOK || !filesOK {
+		d.skip("Pending migrations", "Missing migrations", "Checksums")
+		return d.checks
+	}
+	d.checkMigrationState(ctx, conn, files)
+
+	return d.checks
+}
+
+// Failed returns the first failed check, if any
+func Failed(checks []Check) *Check {
+	for i := range checks {
+		if checks[i].Status == Fail {
+			return &checks[i]
+		}
+	}
+	return nil
+}
+
+// checkConfig validates the configuration file
+func (d *Doctor) checkConfig() bool {
+	return d.run("Configuration", func() (Status, string, string, error) {
+		if err := config.NewValidator(d.config).Validate(); err != nil {
+			return Fail, "Configuration is invalid", "Fix the reported setting in config/database.yaml or the VORM_* environment variables", err
+		}
+		return Pass, "Configuration is valid", "", nil
+	})
+}
+
+// checkEnvironment validates the environment name
+func (d *Doctor) checkEnvironment() {
+	d.run("Environment", func() (Status, string, string, error) {
+		if err := config.NewValidator(d.config).ValidateEnvironment(); err != nil {
+			return Fail, "Unknown environment", "Set environment to development, staging, production or testing", err
+		}
+		message := fmt.Sprintf("Environment is %s", d.config.Environment)
+		if d.config.IsProduction() {
+			message += " (protected by the production policy)"
+		}
+		return Pass, message, "", nil
+	})
+}
+
+// checkFilePermissions validates access to the migrations and log directories
+func (d *Doctor) checkFilePermissions() {
+	d.run("File permissions", func() (Status, string, string, error) {
+		if err := config.NewValidator(d.config).ValidateFilePermissions(); err != nil {
+			return Fail, "Cannot read or write a vorm directory", "Fix the permissions of migration.directory and logging.directory", err
+		}
+		return Pass, "Migrations and log directories are accessible", "", nil
+	})
+}
+
+// checkMigrationFiles loads and parses every migration file
+func (d *Doctor) checkMigrationFiles() ([]*migration.Migration, bool) {
+	var files []*migration.Migration
+	ok := d.run("Migration files", func() (Status, string, string, error) {
+		generator := migration.NewGenerator(d.config)
+		var err error
+		if files, err = generator.LoadMigrations(); err != nil {
+			return Fail, "Cannot load migration files", "Fix or rename the reported file", err
+		}
+		return Pass, fmt.Sprintf("%d migration file(s) in %s", len(files), generator.Source()), "", nil
+	})
+	return files, ok
+}
+
+// checkServerVersion requires PostgreSQL 12 or newer
+func (d *Doctor) checkServerVersion(ctx context.Context, conn *database.Connection) {
+	d.run("Server version", func() (Status, string, string, error) {
+		var version string
+		var versionNum int
+		err := conn.QueryRow(ctx, `SELECT current_setting('server_version'), current_setting('server_version_num')::int`).Scan(&version, &versionNum)
+		if err != nil {
+			return Fail, "Cannot read the server version", "", err
+		}
+		if versionNum < minServerVersion {
+			return Fail, fmt.Sprintf("PostgreSQL %s is not supported", version), "Upgrade to PostgreSQL 12 or newer", nil
+		}
+		return Pass, fmt.Sprintf("PostgreSQL %s", version), "", nil
+	})
+}
+
+// checkExtensions looks for functions the migration templates rely on
+func (d *Doctor) checkExtensions(ctx context.Context, conn *database.Connection) {
+	d.run("Extensions", func() (Status, string, string, error) {
+		// gen_random_uuid is built in from PostgreSQL 13, pgcrypto provides it before
+		var available bool
+		err := conn.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'gen_random_uuid')`).Scan(&available)
+		if err != nil {
+			return Fail, "Cannot check available functions", "", err
+		}
+		if !available {
+			return Warn, "gen_random_uuid() is not available; generated CREATE TABLE migrations use it", "Run CREATE EXTENSION IF NOT EXISTS pgcrypto", nil
+		}
+		return Pass, "gen_random_uuid() is available", "", nil
+	})
+}
+
+// checkClockSkew compares the local clock with the server's
+// Migration timestamps are taken locally, audit timestamps on the server
+func (d *Doctor) checkClockSkew(ctx context.Context, conn *database.Connection) {
+	d.run("Clock skew", func() (Status, string, string, error) {
+		start := time.Now()
+		var serverTime time.Time
+		if err := conn.QueryRow(ctx, `SELECT clock_timestamp()`).Scan(&serverTime); err != nil {
+			return Fail, "Cannot read the server clock", "", err
+		}
+		roundTrip := time.Since(start)
+
+		skew := serverTime.Sub(start.Add(roundTrip / 2))
+		if skew < 0 {
+			skew = -skew
+		}
+		skew = skew.Round(time.Millisecond)
+
+		hint := "Synchronize the clocks with NTP"
+		switch {
+		case skew > maxClockSkewFatal:
+			return Fail, fmt.Sprintf("Local and server clocks differ by %s", skew), hint, nil
+		case skew > maxClockSkew:
+			return Warn, fmt.Sprintf("Local and server clocks differ by %s", skew), hint, nil
+		}
+		return Pass, fmt.Sprintf("Clocks differ by %s", skew), "", nil
+	})
+}
+
+// checkSchemaVersion compares vorm's metadata schema with this build
+func (d *Doctor) checkSchemaVersion(ctx context.Context, conn *database.Connection) {
+	d.run("Schema version", func() (Status, string, string, error) {
+		state, err := database.NewCreator(d.config).SchemaState(ctx, conn)
+		if err != nil {
+			return Fail, "Cannot read the schema version", "", err
+		}
+		switch {
+		case state.Version > database.SchemaVersion:
+			return Fail, fmt.Sprintf("Schema version %d was applied by vorm %s; this build supports %d", state.Version, state.VormVersion, database.SchemaVersion), "Upgrade vorm", nil
+		case state.Version < database.SchemaVersion:
+			return Warn, fmt.Sprintf("Schema version %d, this build uses %d", state.Version, database.SchemaVersion), "Run 'vorm setup' or any migrate command to upgrade", nil
+		}
+		return Pass, fmt.Sprintf("Schema version %d", state.Version), "", nil
+	})
+}
+
+// checkMigrationState reports pending, missing and modified migrations
+func (d *Doctor) checkMigrationState(ctx context.Context, conn *database.Connection, files []*migration.Migration) {
+	tracker := migration.NewTracker(d.config, conn, d.logger)
+
+	executed, err := tracker.GetExecutedMigrations(ctx)
+	if err != nil {
+		d.add(Check{Name: "Pending migrations", Status: Fail, Message: "Cannot read applied migrations", Err: err})
+		d.skip("Missing migrations", "Checksums")
+		return
+	}
+
+	applied := make(map[string]bool)
+	for _, m := range executed {
+		applied[m.Name] = true
+	}
+	onDisk := make(map[string]bool)
+	for _, m := range files {
+		onDisk[m.Name] = true
+	}
+
+	// Pending: files that haven't run
+	var pending []string
+	for _, m := range files {
+		if !applied[m.Name] {
+			pending = append(pending, m.Name)
+		}
+	}
+	if len(pending) > 0 {
+		d.add(Check{Name: "Pending migrations", Status: Warn,
+			Message: fmt.Sprintf("%d pending: %s", len(pending), summarize(pending)),
+			Hint:    "Run 'vorm migrate'"})
+	} else {
+		d.add(Check{Name: "Pending migrations", Status: Pass, Message: "Database is up to date"})
+	}
+
+	// Missing: applied migrations without a file, which can't be rolled back
+	var missing []string
+	for _, m := range executed {
+		if !onDisk[m.Name] {
+			missing = append(missing, m.Name)
+		}
+	}
+	if len(missing) > 0 {
+		d.add(Check{Name: "Missing migrations", Status: Warn,
+			Message: fmt.Sprintf("%d applied migration(s) have no file: %s", len(missing), summarize(missing)),
+			Hint:    "Restore the files from version control; they are needed to roll back"})
+	} else {
+		d.add(Check{Name: "Missing migrations", Status: Pass, Message: "Every applied migration has a file"})
+	}
+
+	// Checksums: applied files edited since they ran
+	var modified []string
+	var driftErr error
+	for _, m := range files {
+		if !applied[m.Name] {
+			continue
+		}
+		if err := tracker.VerifyChecksum(ctx, m); err != nil {
+			var migrationErr *errors.MigrationError
+			if !stderrors.As(err, &migrationErr) || migrationErr.Type != errors.TypeDrift {
+				d.add(Check{Name: "Checksums", Status: Fail, Message: "Cannot verify checksums", Err: err})
+				return
+			}
+			modified = append(modified, m.Name)
+			driftErr = err
+		}
+	}
+	if len(modified) > 0 {
+		d.add(Check{Name: "Checksums", Status: Fail,
+			Message: fmt.Sprintf("%d applied migration(s) were modified: %s", len(modified), summarize(modified)),
+			Hint:    "Revert the edits and put the change in a new migration",
+			Err:     driftErr})
+		return
+	}
+	d.add(Check{Name: "Checksums", Status: Pass, Message: "Applied migrations match their files"})
+}
+
+// run records the result of fn and reports whether it didn't fail
+func (d *Doctor) run(name string, fn func() (Status, string, string, error)) bool {
+	status, message, hint, err := fn()
+	d.add(Check{Name: name, Status: status, Message: message, Hint: hint, Err: err})
+	return status != Fail
+}
+
+// add records a check and logs it
+func (d *Doctor) add(check Check) {
+	if check.Err != nil && !strings.Contains(check.Message, check.Err.Error()) {
+		check.Message = fmt.Sprintf("%s: %v", check.Message, check.Err)
+	}
+	d.checks = append(d.checks, check)
+	d.logger.Debug("Doctor", fmt.Sprintf("%s: %s - %s", check.Name, check.Status, check.Message))
+}
+
+// skip records checks that couldn't run
+func (d *Doctor) skip(names ...string) {
+	for _, name := range names {
+		d.add(Check{Name: name, Status: Skip, Message: "Skipped because an earlier check failed"})
+	}
+}
+
+// summarize lists up to three names
+func summarize(names []string) string {
+	if len(names) <= 3 {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:3], ", "), len(names)-3)
+}
@@ -0,0 +1,183 @@
+package fixtures
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vorzela/vorm/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Keys with a special meaning in YAML and JSON fixtures
+const (
+	labelKey = "$label" // Names a row so other rows can reference it
+	refKey   = "$ref"   // {$ref: table.label} or {$ref: table.label.column}
+	sqlKey   = "$sql"   // {$sql: now()} inserts the result of a SQL expression
+)
+
+// Row is a fixture row to insert
+type Row struct {
+	Label  string
+	Values map[string]any // Column name to value
+}
+
+// Table holds the fixtures one file has for a table
+type Table struct {
+	Name    string   // Table name, optionally schema-qualified
+	Source  string   // Fixture file
+	Rows    []Row    // YAML and JSON fixtures
+	Columns []string // CSV header; the file is loaded with COPY
+}
+
+// IsCSV returns true if the table's rows are copied from a CSV file
+func (t *Table) IsCSV() bool {
+	return t.Columns != nil
+}
+
+// Supported reports whether path has a fixture file extension
+func Supported(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json", ".csv":
+		return true
+	}
+	return false
+}
+
+// ExpandPaths turns files, directories and glob patterns into the fixture
+// files they name. Directories contribute their fixture files, sorted by name
+func ExpandPaths(patterns []string) ([]string, error) {
+	var paths []string
+	for _, pattern := range patterns {
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(pattern); err != nil {
+				return nil, errors.NewValidationError("Invalid fixture pattern", fmt.Sprintf("%s: %v", pattern, err))
+			}
+			if len(matches) == 0 {
+				return nil, errors.NewFileError("No fixture files found", pattern)
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, errors.NewFileError("Failed to access fixtures", err.Error()).WithCause(err)
+			}
+			if !info.IsDir() {
+				paths = append(paths, match)
+				continue
+			}
+
+			entries, err := os.ReadDir(match)
+			if err != nil {
+				return nil, errors.NewFileError("Failed to read fixtures directory", err.Error()).WithCause(err)
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() && Supported(entry.Name()) {
+					paths = append(paths, filepath.Join(match, entry.Name()))
+				}
+			}
+		}
+	}
+	return paths, nil
+}
+
+// ReadFiles parses fixture files into tables, in file order
+// YAML and JSON files map table names to lists of rows; a CSV file holds
+// the rows of the table it is named after, e.g. users.csv
+func ReadFiles(paths []string) ([]*Table, error) {
+	var tables []*Table
+	for _, path := range paths {
+		var (
+			fileTables []*Table
+			err        error
+		)
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			fileTables, err = readDocument(path, yaml.Unmarshal)
+		case ".json":
+			fileTables, err = readDocument(path, unmarshalJSON)
+		case ".csv":
+			fileTables, err = readCSV(path)
+		default:
+			return nil, errors.NewValidationError("Unsupported fixture file", fmt.Sprintf("%s: use .yaml, .yml, .json or .csv", path))
+		}
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, fileTables...)
+	}
+	return tables, nil
+}
+
+// unmarshalJSON decodes JSON keeping numbers as written
+func unmarshalJSON(data []byte, v any) error {
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// readDocument reads a YAML or JSON fixture file
+func readDocument(path string, unmarshal func([]byte, any) error) ([]*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewFileError("Failed to read fixture file", err.Error()).WithCause(err)
+	}
+
+	var document map[string][]map[string]any
+	if err := unmarshal(data, &document); err != nil {
+		return nil, errors.NewValidationError("Invalid fixture file",
+			fmt.Sprintf("%s: %v; expected table names mapped to lists of rows", path, err)).WithCause(err)
+	}
+
+	// Tables are ordered by their foreign keys later; sorting keeps the
+	// order of unrelated tables stable
+	names := make([]string, 0, len(document))
+	for name := range document {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tables []*Table
+	for _, name := range names {
+		table := &Table{Name: name, Source: path}
+		for i, values := range document[name] {
+			row := Row{Values: values}
+			if label, ok := values[labelKey]; ok {
+				row.Label, ok = label.(string)
+				if !ok || row.Label == "" {
+					return nil, errors.NewValidationError("Invalid fixture label",
+						fmt.Sprintf("%s: row %d of %s: %s must be a non-empty string", path, i+1, name, labelKey))
+				}
+				delete(values, labelKey)
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// readCSV reads the header of a CSV fixture; the rows are streamed on load
+func readCSV(path string) ([]*Table, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.NewFileError("Failed to read fixture file", err.Error()).WithCause(err)
+	}
+	defer file.Close()
+
+	header, err := csv.NewReader(file).Read()
+	if err != nil {
+		return nil, errors.NewValidationError("Invalid CSV fixture",
+			fmt.Sprintf("%s: %v; the first line must name the columns", path, err)).WithCause(err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return []*Table{{Name: name, Source: path, Columns: header}}, nil
+}
@@ -0,0 +1,437 @@
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// Options controls how fixtures are loaded
+type Options struct {
+	Truncate bool // Empty the fixture tables first, restarting their sequences
+}
+
+// Result reports the rows loaded into a table from one file
+type Result struct {
+	Table  string `json:"table" yaml:"table"`
+	Source string `json:"source" yaml:"source"`
+	Rows   int64  `json:"rows" yaml:"rows"`
+}
+
+// Loader inserts fixtures into a database
+type Loader struct {
+	conn *pgx.Conn
+	opts Options
+}
+
+// NewLoader creates a loader for conn
+func NewLoader(conn *pgx.Conn, opts Options) *Loader {
+	return &Loader{conn: conn, opts: opts}
+}
+
+// load is the state of a single Load call
+type load struct {
+	tx          pgx.Tx
+	tables      []string                      // Fixture table names, longest first, to resolve references
+	labels      map[string]map[string]*string // "table.label" to the row's columns as text
+	primaryKeys map[string]string             // Table name to its single-column primary key
+	oids        map[string]uint32
+}
+
+// Load inserts the fixtures read by ReadFiles in one transaction
+// Tables are filled in foreign-key order, parents first, so rows can
+// reference rows of other fixture tables
+func (l *Loader) Load(ctx context.Context, tables []*Table) ([]Result, error) {
+	if len(tables) == 0 {
+		return nil, nil
+	}
+
+	tx, err := l.conn.Begin(ctx)
+	if err != nil {
+		return nil, errors.NewMigrationError("Failed to begin transaction", err.Error(), "").WithCause(err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	state := &load{
+		tx:          tx,
+		labels:      make(map[string]map[string]*string),
+		primaryKeys: make(map[string]string),
+		oids:        make(map[string]uint32),
+	}
+
+	order, err := state.sortTables(ctx, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.opts.Truncate {
+		if err := state.truncate(ctx, order); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []Result
+	for _, name := range order {
+		for _, table := range tables {
+			if table.Name != name {
+				continue
+			}
+
+			var rows int64
+			if table.IsCSV() {
+				rows, err = state.copyCSV(ctx, table)
+			} else {
+				rows, err = state.insertRows(ctx, table)
+			}
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, Result{Table: table.Name, Source: table.Source, Rows: rows})
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.NewMigrationError("Failed to commit fixtures", err.Error(), "").WithCause(err)
+	}
+	return results, nil
+}
+
+// sortTables returns the fixture table names in foreign-key order
+// Tables that don't depend on each other keep the order they were read in
+func (s *load) sortTables(ctx context.Context, tables []*Table) ([]string, error) {
+	var names []string
+	for _, table := range tables {
+		if _, seen := s.oids[table.Name]; seen {
+			continue
+		}
+
+		var oid *uint32
+		if err := s.tx.QueryRow(ctx, `SELECT to_regclass($1)::oid`, identifier(table.Name)).Scan(&oid); err != nil {
+			return nil, errors.NewMigrationError("Failed to look up fixture table", err.Error(), "").WithCause(err)
+		}
+		if oid == nil {
+			return nil, errors.NewValidationError("Fixture table does not exist", fmt.Sprintf("%s (from %s)", table.Name, table.Source))
+		}
+		s.oids[table.Name] = *oid
+		names = append(names, table.Name)
+	}
+
+	s.tables = append([]string(nil), names...)
+	sort.Slice(s.tables, func(i, j int) bool { return len(s.tables[i]) > len(s.tables[j]) })
+
+	oids := make([]uint32, 0, len(names))
+	byOID := make(map[uint32]string, len(names))
+	for _, name := range names {
+		oids = append(oids, s.oids[name])
+		byOID[s.oids[name]] = name
+	}
+
+	// Self-references don't affect the order; rows of such tables are
+	// inserted in file order
+	rows, err := s.tx.Query(ctx, `
+		SELECT conrelid, confrelid
+		FROM pg_constraint
+		WHERE contype = 'f' AND conrelid = ANY($1) AND confrelid = ANY($1) AND conrelid <> confrelid
+	`, oids)
+	if err != nil {
+		return nil, errors.NewMigrationError("Failed to read foreign keys", err.Error(), "").WithCause(err)
+	}
+	parents := make(map[string][]string)
+	for rows.Next() {
+		var child, parent uint32
+		if err := rows.Scan(&child, &parent); err != nil {
+			rows.Close()
+			return nil, errors.NewMigrationError("Failed to read foreign keys", err.Error(), "").WithCause(err)
+		}
+		parents[byOID[child]] = append(parents[byOID[child]], byOID[parent])
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewMigrationError("Failed to read foreign keys", err.Error(), "").WithCause(err)
+	}
+
+	// Repeatedly take the first table whose parents are all placed
+	var order []string
+	placed := make(map[string]bool, len(names))
+	for len(order) < len(names) {
+		progressed := false
+		for _, name := range names {
+			if placed[name] || !allPlaced(parents[name], placed) {
+				continue
+			}
+			order = append(order, name)
+			placed[name] = true
+			progressed = true
+			break
+		}
+
+		if !progressed {
+			var cycle []string
+			for _, name := range names {
+				if !placed[name] {
+					cycle = append(cycle, name)
+				}
+			}
+			return nil, errors.NewValidationError("Foreign keys between fixture tables form a cycle",
+				fmt.Sprintf("can't order %s; load them with separate fixtures:load runs", strings.Join(cycle, ", ")))
+		}
+	}
+	return order, nil
+}
+
+// allPlaced returns true if every table in names is placed
+func allPlaced(names []string, placed map[string]bool) bool {
+	for _, name := range names {
+		if !placed[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// truncate empties the fixture tables in a single statement, so foreign
+// keys between them don't get in the way
+func (s *load) truncate(ctx context.Context, names []string) error {
+	identifiers := make([]string, 0, len(names))
+	for _, name := range names {
+		identifiers = append(identifiers, identifier(name))
+	}
+
+	sql := fmt.Sprintf("TRUNCATE %s RESTART IDENTITY", strings.Join(identifiers, ", "))
+	if _, err := s.tx.Exec(ctx, sql); err != nil {
+		return errors.NewMigrationError("Failed to truncate fixture tables", err.Error(), "").WithCause(err)
+	}
+	return nil
+}
+
+// copyCSV streams a CSV fixture into its table with COPY
+// Unquoted empty fields are loaded as NULL
+func (s *load) copyCSV(ctx context.Context, table *Table) (int64, error) {
+	file, err := os.Open(table.Source)
+	if err != nil {
+		return 0, errors.NewFileError("Failed to read fixture file", err.Error()).WithCause(err)
+	}
+	defer file.Close()
+
+	columns := make([]string, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		columns = append(columns, pgx.Identifier{strings.TrimSpace(column)}.Sanitize())
+	}
+
+	sql := fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (FORMAT csv, HEADER true)",
+		identifier(table.Name), strings.Join(columns, ", "))
+	tag, err := s.tx.Conn().PgConn().CopyFrom(ctx, file, sql)
+	if err != nil {
+		return 0, errors.NewMigrationError(fmt.Sprintf("Failed to copy %s into %s", table.Source, table.Name), err.Error(), "").WithCause(err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// insertRows inserts the rows of a YAML or JSON fixture one by one
+// Labelled rows are read back, so later rows can reference their columns
+func (s *load) insertRows(ctx context.Context, table *Table) (int64, error) {
+	for i, row := range table.Rows {
+		fail := func(message, details string) error {
+			return errors.NewValidationError(message, fmt.Sprintf("%s: row %d of %s: %s", table.Source, i+1, table.Name, details))
+		}
+
+		key := table.Name + "." + row.Label
+		if row.Label != "" {
+			if _, exists := s.labels[key]; exists {
+				return 0, fail("Duplicate fixture label", row.Label)
+			}
+		}
+
+		columns := make([]string, 0, len(row.Values))
+		for column := range row.Values {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		var identifiers, values []string
+		var args []any
+		for _, column := range columns {
+			value, err := s.value(ctx, row.Values[column])
+			if err != nil {
+				return 0, fail("Invalid fixture value", fmt.Sprintf("%s: %v", column, err))
+			}
+
+			identifiers = append(identifiers, pgx.Identifier{column}.Sanitize())
+			if expression, ok := value.(sqlExpression); ok {
+				values = append(values, "("+string(expression)+")")
+				continue
+			}
+			args = append(args, value)
+			values = append(values, "$"+strconv.Itoa(len(args)))
+		}
+
+		sql := fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", identifier(table.Name))
+		if len(columns) > 0 {
+			sql = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+				identifier(table.Name), strings.Join(identifiers, ", "), strings.Join(values, ", "))
+		}
+
+		if row.Label == "" {
+			if _, err := s.tx.Exec(ctx, sql, args...); err != nil {
+				return 0, insertFailed(table, i, err)
+			}
+			continue
+		}
+
+		inserted, err := s.insertReturning(ctx, sql+" RETURNING *", args)
+		if err != nil {
+			return 0, insertFailed(table, i, err)
+		}
+		s.labels[key] = inserted
+	}
+	return int64(len(table.Rows)), nil
+}
+
+// insertReturning runs an INSERT ... RETURNING * and returns the inserted
+// row's columns as text, nil for NULL
+func (s *load) insertReturning(ctx context.Context, sql string, args []any) (map[string]*string, error) {
+	rows, err := s.tx.Query(ctx, sql, append([]any{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inserted := make(map[string]*string)
+	if rows.Next() {
+		for i, field := range rows.FieldDescriptions() {
+			if raw := rows.RawValues()[i]; raw != nil {
+				text := string(raw)
+				inserted[field.Name] = &text
+			} else {
+				inserted[field.Name] = nil
+			}
+		}
+	}
+	return inserted, rows.Err()
+}
+
+// insertFailed reports a row PostgreSQL rejected
+func insertFailed(table *Table, index int, err error) error {
+	return errors.NewMigrationError(
+		fmt.Sprintf("Failed to insert row %d of %s into %s", index+1, table.Source, table.Name),
+		err.Error(), "").WithCause(err)
+}
+
+// sqlExpression is a {$sql: ...} value, inlined into the INSERT
+type sqlExpression string
+
+// value converts a fixture value to a query argument
+// Values are sent as text and parsed by PostgreSQL according to the
+// column's type; lists and maps other than $ref and $sql become JSON
+func (s *load) value(ctx context.Context, value any) (any, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case json.Number:
+		return v.String(), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case map[string]any:
+		if len(v) == 1 {
+			if ref, ok := v[refKey].(string); ok {
+				return s.reference(ctx, ref)
+			}
+			if expression, ok := v[sqlKey].(string); ok {
+				return sqlExpression(expression), nil
+			}
+		}
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// reference resolves "table.label" to the primary key of a labelled row,
+// or "table.label.column" to one of its columns
+func (s *load) reference(ctx context.Context, ref string) (any, error) {
+	for _, table := range s.tables {
+		rest, ok := strings.CutPrefix(ref, table+".")
+		if !ok {
+			continue
+		}
+
+		label, column, hasColumn := strings.Cut(rest, ".")
+		inserted, ok := s.labels[table+"."+label]
+		if !ok {
+			return nil, fmt.Errorf("%s %q: no row labelled %q has been loaded into %s (tables load in foreign-key order)", refKey, ref, label, table)
+		}
+
+		if !hasColumn {
+			var err error
+			if column, err = s.primaryKey(ctx, table); err != nil {
+				return nil, err
+			}
+		}
+		value, ok := inserted[column]
+		if !ok {
+			return nil, fmt.Errorf("%s %q: %s has no column %q", refKey, ref, table, column)
+		}
+		if value == nil {
+			return nil, nil
+		}
+		return *value, nil
+	}
+	return nil, fmt.Errorf("%s %q: expected table.label or table.label.column of a fixture table", refKey, ref)
+}
+
+// primaryKey returns the single-column primary key of table
+func (s *load) primaryKey(ctx context.Context, table string) (string, error) {
+	if column, ok := s.primaryKeys[table]; ok {
+		return column, nil
+	}
+
+	rows, err := s.tx.Query(ctx, `
+		SELECT a.attname
+		FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = $1 AND i.indisprimary
+	`, s.oids[table])
+	if err != nil {
+		return "", err
+	}
+	columns, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return "", err
+	}
+	if len(columns) != 1 {
+		return "", fmt.Errorf("%s has no single-column primary key; reference a column as table.label.column", table)
+	}
+
+	s.primaryKeys[table] = columns[0]
+	return columns[0], nil
+}
+
+// identifier quotes a table name, which may be schema-qualified
+func identifier(name string) string {
+	return pgx.Identifier(strings.Split(name, ".")).Sanitize()
+}
@@ -0,0 +1,78 @@
+package logger
+
+// Fields are structured attributes attached to a log event
+// Common keys: run_id, command, migration, batch, duration_ms,
+// statement_index, sqlstate, environment and db
+type Fields map[string]interface{}
+
+// FieldLogger is implemented by loggers that can record structured fields
+type FieldLogger interface {
+	Logger
+	LogFields(level LogLevel, category, message string, fields Fields)
+}
+
+// Event logs a message with fields, falling back to the plain message
+// when the logger doesn't support structured fields
+func Event(l Logger, level LogLevel, category, message string, fields Fields) {
+	if fl, ok := l.(FieldLogger); ok {
+		fl.LogFields(level, category, message, fields)
+		return
+	}
+
+	switch level {
+	case DEBUG:
+		l.Debug(category, message)
+	case INFO:
+		l.Info(category, message)
+	case SUCCESS:
+		l.Success(category, message)
+	case WARNING:
+		l.Warning(category, message)
+	default:
+		l.Error(category, message)
+	}
+}
+
+// contextLogger attaches a shared set of fields to every event
+type contextLogger struct {
+	next   Logger
+	fields Fields
+}
+
+// WithFields returns a logger that adds fields to every event logged through it
+// The map is read on every event, so later changes to it are picked up
+func WithFields(l Logger, fields Fields) FieldLogger {
+	return &contextLogger{next: l, fields: fields}
+}
+
+func (l *contextLogger) Debug(category, message string) {
+	l.LogFields(DEBUG, category, message, nil)
+}
+
+func (l *contextLogger) Info(category, message string) {
+	l.LogFields(INFO, category, message, nil)
+}
+
+func (l *contextLogger) Success(category, message string) {
+	l.LogFields(SUCCESS, category, message, nil)
+}
+
+func (l *contextLogger) Warning(category, message string) {
+	l.LogFields(WARNING, category, message, nil)
+}
+
+func (l *contextLogger) Error(category, message string) {
+	l.LogFields(ERROR, category, message, nil)
+}
+
+// LogFields merges the shared fields with the event fields
+func (l *contextLogger) LogFields(level LogLevel, category, message string, fields Fields) {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for key, value := range l.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	Event(l.next, level, category, message, merged)
+}
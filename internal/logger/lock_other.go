@@ -0,0 +1,28 @@
+//go:build !unix && !windows
+
+package logger
+
+import "os"
+
+// fileLock is a no-op on platforms without file locking
+// Writes from a single process are still serialized by rotatingFile
+type fileLock struct {
+	file *os.File
+}
+
+// openFileLock opens (creating if needed) the lock file at path
+func openFileLock(path string) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileLock{file: file}, nil
+}
+
+func (l *fileLock) lock() error   { return nil }
+func (l *fileLock) unlock() error { return nil }
+
+// close releases the lock file
+func (l *fileLock) close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package logger
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileLock is an advisory lock shared by all processes using the same log file
+type fileLock struct {
+	file *os.File
+}
+
+// openFileLock opens (creating if needed) the lock file at path
+func openFileLock(path string) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileLock{file: file}, nil
+}
+
+// lock blocks until the exclusive lock is acquired
+func (l *fileLock) lock() error {
+	return syscall.Flock(int(l.file.Fd()), syscall.LOCK_EX)
+}
+
+// unlock releases the lock
+func (l *fileLock) unlock() error {
+	return syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+}
+
+// close releases the lock file
+func (l *fileLock) close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,40 @@
+//go:build windows
+
+package logger
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// fileLock is an exclusive lock shared by all processes using the same log file
+type fileLock struct {
+	file *os.File
+}
+
+// openFileLock opens (creating if needed) the lock file at path
+func openFileLock(path string) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileLock{file: file}, nil
+}
+
+// lock blocks until the exclusive lock is acquired
+func (l *fileLock) lock() error {
+	overlapped := new(windows.Overlapped)
+	return windows.LockFileEx(windows.Handle(l.file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, overlapped)
+}
+
+// unlock releases the lock
+func (l *fileLock) unlock() error {
+	overlapped := new(windows.Overlapped)
+	return windows.UnlockFileEx(windows.Handle(l.file.Fd()), 0, 1, 0, overlapped)
+}
+
+// close releases the lock file
+func (l *fileLock) close() error {
+	return l.file.Close()
+}
@@ -1,9 +1,10 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/vorzela/vorm/internal/config"
@@ -43,17 +44,27 @@ func (l LogLevel) String() string {
 	}
 }
 
-// Logger handles logging operations as specified in AINOTES.md
-type Logger struct {
+// Logger receives progress messages from migration components
+// ConsoleLogger is the CLI implementation; library users can plug in their own
+type Logger interface {
+	Debug(category, message string)
+	Info(category, message string)
+	Success(category, message string)
+	Warning(category, message string)
+	Error(category, message string)
+}
+
+// ConsoleLogger prints colored messages to the console and optionally a log file
+type ConsoleLogger struct {
 	config   *config.Config
-	logFile  *os.File
+	logFile  *rotatingFile
 	enabled  bool
 	minLevel LogLevel
 }
 
-// NewLogger creates a new logger instance
-func NewLogger(cfg *config.Config) (*Logger, error) {
-	logger := &Logger{
+// NewLogger creates a new console logger instance
+func NewLogger(cfg *config.Config) (*ConsoleLogger, error) {
+	logger := &ConsoleLogger{
 		config:   cfg,
 		enabled:  cfg.Logging.Enabled,
 		minLevel: parseLogLevel(cfg.Logging.Level),
@@ -69,18 +80,19 @@ func NewLogger(cfg *config.Config) (*Logger, error) {
 }
 
 // initLogFile initializes the log file
-func (l *Logger) initLogFile() error {
+func (l *ConsoleLogger) initLogFile() error {
 	// Ensure log directory exists
 	logDir := l.config.GetLogsPath()
 	if err := utils.EnsureDirectoryExists(logDir); err != nil {
 		return fmt.Errorf("failed to create log directory: %v", err)
 	}
 
-	// Open log file
-	logPath := filepath.Join(logDir, l.config.Logging.Filename)
-	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	// Open log file with rotation
+	logging := l.config.Logging
+	logPath := filepath.Join(logDir, logging.Filename)
+	file, err := openRotatingFile(logPath, logging.MaxSize, logging.MaxBackups, logging.MaxAge, logging.Compress)
 	if err != nil {
-		return fmt.Errorf("failed to open log file: %v", err)
+		return err
 	}
 
 	l.logFile = file
@@ -88,7 +100,7 @@ func (l *Logger) initLogFile() error {
 }
 
 // Close closes the logger
-func (l *Logger) Close() error {
+func (l *ConsoleLogger) Close() error {
 	if l.logFile != nil {
 		return l.logFile.Close()
 	}
@@ -96,7 +108,13 @@ func (l *Logger) Close() error {
 }
 
 // Log writes a log entry with the specified level
-func (l *Logger) Log(level LogLevel, category, message string) {
+func (l *ConsoleLogger) Log(level LogLevel, category, message string) {
+	l.LogFields(level, category, message, nil)
+}
+
+// LogFields writes a log entry with structured fields
+// Fields are only written to the log file; the console shows the message
+func (l *ConsoleLogger) LogFields(level LogLevel, category, message string, fields Fields) {
 	if level < l.minLevel {
 		return
 	}
@@ -106,37 +124,64 @@ func (l *Logger) Log(level LogLevel, category, message string) {
 
 	// File logging if enabled
 	if l.enabled && l.logFile != nil {
-		l.logToFile(level, category, message)
+		if l.config.Logging.Format == "json" {
+			l.logJSONToFile(level, category, message, fields)
+		} else {
+			l.logToFile(level, category, message)
+		}
 	}
 }
 
 // logToConsole outputs colored log messages to console
-func (l *Logger) logToConsole(level LogLevel, category, message string) {
+func (l *ConsoleLogger) logToConsole(level LogLevel, category, message string) {
 	timestamp := time.Now().Format("15:04:05")
 	logMsg := fmt.Sprintf("[%s] %s", timestamp, message)
 
 	switch level {
 	case SUCCESS:
-		console.ColorSuccess.Printf("✓ %s\n", logMsg)
+		console.ColorSuccess.Fprintf(console.Messages, "✓ %s\n", logMsg)
 	case ERROR, FATAL:
-		console.ColorError.Printf("✗ %s\n", logMsg)
+		console.ColorError.Fprintf(console.Messages, "✗ %s\n", logMsg)
 	case WARNING:
-		console.ColorWarning.Printf("⚠ %s\n", logMsg)
+		console.ColorWarning.Fprintf(console.Messages, "⚠ %s\n", logMsg)
 	case INFO:
-		console.ColorInfo.Printf("ℹ %s\n", logMsg)
+		console.ColorInfo.Fprintf(console.Messages, "ℹ %s\n", logMsg)
 	case DEBUG:
-		console.ColorDebug.Printf("🐛 %s\n", logMsg)
+		console.ColorDebug.Fprintf(console.Messages, "🐛 %s\n", logMsg)
 	}
 }
 
 // logToFile writes log entry to file in the format specified in AINOTES.md
-func (l *Logger) logToFile(level LogLevel, category, message string) {
+func (l *ConsoleLogger) logToFile(level LogLevel, category, message string) {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	logEntry := fmt.Sprintf("[%s] [%s] [%s] %s\n", timestamp, level.String(), category, message)
 
 	if _, err := l.logFile.WriteString(logEntry); err != nil {
 		// If we can't write to file, output to console instead
-		console.ColorError.Printf("✗ Failed to write to log file: %v\n", err)
+		console.ColorError.Fprintf(console.Messages, "✗ Failed to write to log file: %v\n", err)
+	}
+}
+
+// logJSONToFile writes log entry to file as a single JSON object per line
+func (l *ConsoleLogger) logJSONToFile(level LogLevel, category, message string, fields Fields) {
+	entry := make(map[string]interface{}, len(fields)+4)
+	for key, value := range fields {
+		entry[key] = value
+	}
+	entry["time"] = time.Now().Format(time.RFC3339Nano)
+	entry["level"] = strings.ToLower(level.String())
+	entry["category"] = category
+	entry["message"] = message
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		console.ColorError.Fprintf(console.Messages, "✗ Failed to encode log entry: %v\n", err)
+		return
+	}
+
+	if _, err := l.logFile.Write(append(data, '\n')); err != nil {
+		// If we can't write to file, output to console instead
+		console.ColorError.Fprintf(console.Messages, "✗ Failed to write to log file: %v\n", err)
 	}
 }
 
@@ -159,51 +204,40 @@ func parseLogLevel(level string) LogLevel {
 }
 
 // Convenience methods for different log levels
-func (l *Logger) Debug(category, message string) {
+func (l *ConsoleLogger) Debug(category, message string) {
 	l.Log(DEBUG, category, message)
 }
 
-func (l *Logger) Info(category, message string) {
+func (l *ConsoleLogger) Info(category, message string) {
 	l.Log(INFO, category, message)
 }
 
-func (l *Logger) Success(category, message string) {
+func (l *ConsoleLogger) Success(category, message string) {
 	l.Log(SUCCESS, category, message)
 }
 
-func (l *Logger) Warning(category, message string) {
+func (l *ConsoleLogger) Warning(category, message string) {
 	l.Log(WARNING, category, message)
 }
 
-func (l *Logger) Error(category, message string) {
+func (l *ConsoleLogger) Error(category, message string) {
 	l.Log(ERROR, category, message)
 }
 
-func (l *Logger) Fatal(category, message string) {
+func (l *ConsoleLogger) Fatal(category, message string) {
 	l.Log(FATAL, category, message)
 }
 
-// Migration-specific logging methods
-func (l *Logger) LogMigrationStart(migration string) {
-	l.Info("Migration", fmt.Sprintf("Starting migration: %s", migration))
-}
-
-func (l *Logger) LogMigrationSuccess(migration string, duration time.Duration) {
-	l.Success("Migration", fmt.Sprintf("Completed: %s (%s)", migration, utils.FormatDuration(duration)))
-}
+// nopLogger discards all messages
+type nopLogger struct{}
 
-func (l *Logger) LogMigrationError(migration string, err error) {
-	l.Error("Migration", fmt.Sprintf("Failed: %s - %v", migration, err))
+// NewNopLogger returns a logger that discards all messages
+func NewNopLogger() Logger {
+	return nopLogger{}
 }
 
-func (l *Logger) LogDatabaseConnection(database string) {
-	l.Info("Database", fmt.Sprintf("Connected to database: %s", database))
-}
-
-func (l *Logger) LogRollbackStart(migration string) {
-	l.Warning("Migration", fmt.Sprintf("Rolling back: %s", migration))
-}
-
-func (l *Logger) LogRollbackSuccess(migration string) {
-	l.Success("Migration", fmt.Sprintf("Rolled back: %s", migration))
-}
+func (nopLogger) Debug(category, message string)   {}
+func (nopLogger) Info(category, message string)    {}
+func (nopLogger) Success(category, message string) {}
+func (nopLogger) Warning(category, message string) {}
+func (nopLogger) Error(category, message string)   {}
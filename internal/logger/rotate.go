@@ -0,0 +1,330 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vorzela/vorm/internal/utils"
+)
+
+// backupTimeFormat is the timestamp embedded in rotated file names
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotatingFile is an append-only log file rotated by size and age
+// Rotated files are optionally gzip compressed and pruned by count and age.
+// A lock file serializes writes and rotation across vorm processes sharing
+// the same log file.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64         // Rotate before the file exceeds this many bytes, 0 disables
+	maxBackups int           // Rotated files to keep, 0 keeps all
+	maxAge     time.Duration // Rotate the file and remove rotated files older than this, 0 keeps all
+	compress   bool
+	file       *os.File
+	started    time.Time // When the active file was started
+	lock       *fileLock
+}
+
+// openRotatingFile opens path for appending with the given rotation settings
+// maxSize is in megabytes and maxAge in days, matching the logging config
+func openRotatingFile(path string, maxSize, maxBackups, maxAge int, compress bool) (*rotatingFile, error) {
+	lock, err := openFileLock(path + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log lock file: %v", err)
+	}
+
+	r := &rotatingFile{
+		path:       path,
+		maxSize:    int64(maxSize) * 1024 * 1024,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAge) * 24 * time.Hour,
+		compress:   compress,
+		lock:       lock,
+	}
+
+	if err := r.open(); err != nil {
+		lock.close()
+		return nil, err
+	}
+
+	// Rotation may not happen for a long time, so don't keep expired
+	// backups until then
+	if err := r.lock.lock(); err != nil {
+		r.Close()
+		return nil, fmt.Errorf("failed to lock log file: %v", err)
+	}
+	err = r.prune()
+	r.lock.unlock()
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Write appends p to the log file, rotating it first if it would grow too
+// large or is older than max_age
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.lock.lock(); err != nil {
+		return 0, fmt.Errorf("failed to lock log file: %v", err)
+	}
+	defer r.lock.unlock()
+
+	// Another process may have rotated the file since we opened it
+	if err := r.reopenIfRotated(); err != nil {
+		return 0, err
+	}
+
+	info, err := r.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	tooLarge := r.maxSize > 0 && info.Size()+int64(len(p)) > r.maxSize
+	tooOld := r.maxAge > 0 && time.Since(r.started) > r.maxAge
+	if info.Size() > 0 && (tooLarge || tooOld) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	return r.file.Write(p)
+}
+
+// WriteString appends s to the log file
+func (r *rotatingFile) WriteString(s string) (int, error) {
+	return r.Write([]byte(s))
+}
+
+// Close closes the log file and its lock file
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lock.close()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// open opens the active log file for appending
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	r.file = file
+	r.started = r.startTime()
+	return nil
+}
+
+// startTime returns when the active file was started: now if it is empty,
+// else when the newest backup was rotated out. A file that was never
+// rotated counts from its last write
+func (r *rotatingFile) startTime() time.Time {
+	info, err := r.file.Stat()
+	if err != nil || info.Size() == 0 {
+		return time.Now()
+	}
+
+	backups, err := r.listBackups()
+	if err != nil || len(backups) == 0 {
+		return info.ModTime()
+	}
+	started := backups[0].timestamp
+	for _, backup := range backups[1:] {
+		if backup.timestamp.After(started) {
+			started = backup.timestamp
+		}
+	}
+	return started
+}
+
+// reopenIfRotated reopens the log file when the path no longer refers to it
+func (r *rotatingFile) reopenIfRotated() error {
+	current, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	onDisk, err := os.Stat(r.path)
+	if err == nil && os.SameFile(current, onDisk) {
+		return nil
+	}
+
+	r.file.Close()
+	return r.open()
+}
+
+// rotate moves the active file aside, opens a new one and cleans up backups
+// Must be called with the lock held
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	// Rotations within the same millisecond must not overwrite each other
+	now := time.Now()
+	backup := r.backupName(now)
+	for utils.FileExists(backup) || utils.FileExists(backup+".gz") {
+		now = now.Add(time.Millisecond)
+		backup = r.backupName(now)
+	}
+
+	if err := os.Rename(r.path, backup); err != nil {
+		// Keep logging to the current file rather than losing entries
+		if openErr := r.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("failed to rotate log file: %v", err)
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	if r.compress {
+		if err := compressFile(backup); err != nil {
+			return fmt.Errorf("failed to compress rotated log file: %v", err)
+		}
+	}
+
+	return r.prune()
+}
+
+// backupName returns the rotated file name for t, e.g. vorm-2025-06-14T18-03-39.000.log
+func (r *rotatingFile) backupName(t time.Time) string {
+	dir := filepath.Dir(r.path)
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext)
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", prefix, t.Format(backupTimeFormat), ext))
+}
+
+// backupFile is a rotated log file found on disk
+type backupFile struct {
+	path      string
+	timestamp time.Time
+}
+
+// prune removes rotated files beyond max_backups or older than max_age
+func (r *rotatingFile) prune() error {
+	if r.maxBackups == 0 && r.maxAge == 0 {
+		return nil
+	}
+
+	backups, err := r.listBackups()
+	if err != nil {
+		return err
+	}
+
+	// Newest first
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].timestamp.After(backups[j].timestamp)
+	})
+
+	cutoff := time.Now().Add(-r.maxAge)
+	for i, backup := range backups {
+		tooMany := r.maxBackups > 0 && i >= r.maxBackups
+		tooOld := r.maxAge > 0 && backup.timestamp.Before(cutoff)
+		if tooMany || tooOld {
+			if err := os.Remove(backup.path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove old log file: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// listBackups returns all rotated files belonging to this log file
+func (r *rotatingFile) listBackups() ([]backupFile, error) {
+	dir := filepath.Dir(r.path)
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log directory: %v", err)
+	}
+
+	var backups []backupFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimPrefix(name, prefix)
+		switch {
+		case strings.HasSuffix(stamp, ext+".gz"):
+			stamp = strings.TrimSuffix(stamp, ext+".gz")
+		case strings.HasSuffix(stamp, ext):
+			stamp = strings.TrimSuffix(stamp, ext)
+		default:
+			continue
+		}
+
+		timestamp, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue // Not one of ours
+		}
+
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), timestamp: timestamp})
+	}
+
+	return backups, nil
+}
+
+// compressFile gzips path to path.gz and removes the original
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Write to a temporary file so a crash never leaves a truncated .gz behind
+	tmpPath := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path+".gz"); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,354 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vorzela/vorm/internal/migration"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// ContentType is the Prometheus text exposition format served by Recorder
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// durationBuckets are the upper bounds of the migration duration histogram, in seconds
+var durationBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900}
+
+// target identifies the database a series describes
+type target struct {
+	environment string
+	database    string
+}
+
+type failureKey struct {
+	target
+	sqlstate string
+}
+
+type durationKey struct {
+	target
+	migration string
+	direction string
+}
+
+// histogram counts observations per bucket; counts are not cumulative
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Recorder collects migration metrics and writes them in the Prometheus
+// text exposition format
+// A single recorder can observe runs against several databases; every
+// series is labelled with the environment and database
+type Recorder struct {
+	mu         sync.Mutex
+	applied    map[target]float64
+	rolledBack map[target]float64
+	lockWait   map[target]float64
+	pending    map[target]float64
+	failures   map[failureKey]float64
+	durations  map[durationKey]*histogram
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		applied:    make(map[target]float64),
+		rolledBack: make(map[target]float64),
+		lockWait:   make(map[target]float64),
+		pending:    make(map[target]float64),
+		failures:   make(map[failureKey]float64),
+		durations:  make(map[durationKey]*histogram),
+	}
+}
+
+// Observer returns an observer recording the runs against database in environment
+func (r *Recorder) Observer(environment, database string) migration.Observer {
+	return &observer{recorder: r, target: target{environment: environment, database: database}}
+}
+
+// SetPending records the number of pending migrations, e.g. from a status check
+func (r *Recorder) SetPending(environment, database string, pending int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[target{environment: environment, database: database}] = float64(pending)
+}
+
+// observe adds a migration duration to its histogram
+func (r *Recorder) observe(key durationKey, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(durationBuckets))}
+		r.durations[key] = h
+	}
+
+	seconds := duration.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// add increases a counter or gauge of t
+func (r *Recorder) add(series map[target]float64, t target, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	series[t] += value
+}
+
+// adjustPending moves the pending gauge of t by delta if it is known
+func (r *Recorder) adjustPending(t target, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pending, ok := r.pending[t]; ok {
+		r.pending[t] = pending + delta
+	}
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var buf bytes.Buffer
+	writeFamily(&buf, "vorm_migrations_applied_total", "Migrations applied.", "counter", targetSamples(r.applied))
+	writeFamily(&buf, "vorm_migrations_rolled_back_total", "Migrations rolled back.", "counter", targetSamples(r.rolledBack))
+	writeFamily(&buf, "vorm_migration_duration_seconds", "Time taken to apply or roll back a migration.", "histogram", r.durationSamples())
+	writeFamily(&buf, "vorm_lock_wait_seconds_total", "Time spent waiting for the migration lock held by another process.", "counter", targetSamples(r.lockWait))
+	writeFamily(&buf, "vorm_migration_failures_total", "Failed runs by PostgreSQL SQLSTATE, none for failures outside SQL.", "counter", r.failureSamples())
+	writeFamily(&buf, "vorm_migrations_pending", "Migrations not yet applied.", "gauge", targetSamples(r.pending))
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// WriteTextfile atomically replaces path with the current metrics, for the
+// node_exporter textfile collector; path should end in .prom
+func (r *Recorder) WriteTextfile(path string) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	// Write next to the target so the rename is atomic; the collector
+	// ignores the temporary file since it doesn't end in .prom
+	tmp, err := os.CreateTemp(dir, "."+name+".tmp*")
+	if err != nil {
+		return errors.NewFileError("Failed to write metrics textfile", err.Error()).WithCause(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	_, err = r.WriteTo(writer)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return errors.NewFileError("Failed to write metrics textfile", err.Error()).WithCause(err)
+	}
+	return nil
+}
+
+// ServeHTTP serves the metrics for Prometheus to scrape
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	if req.Method == http.MethodHead {
+		return
+	}
+	r.WriteTo(w)
+}
+
+// sample is a single line of a metric family
+type sample struct {
+	suffix string // e.g. _bucket for histograms
+	labels string
+	value  float64
+}
+
+// targetSamples returns one sample per target
+func targetSamples(series map[target]float64) []sample {
+	samples := make([]sample, 0, len(series))
+	for t, value := range series {
+		samples = append(samples, sample{labels: t.labels(), value: value})
+	}
+	return samples
+}
+
+// durationSamples returns the bucket, sum and count lines of each histogram
+func (r *Recorder) durationSamples() []sample {
+	var samples []sample
+	for key, h := range r.durations {
+		labels := labelPairs(
+			"database", key.database,
+			"direction", key.direction,
+			"environment", key.environment,
+			"migration", key.migration,
+		)
+
+		var cumulative uint64
+		for i, bound := range durationBuckets {
+			cumulative += h.counts[i]
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			samples = append(samples, sample{suffix: "_bucket", labels: labels + `,le="` + le + `"`, value: float64(cumulative)})
+		}
+		samples = append(samples,
+			sample{suffix: "_bucket", labels: labels + `,le="+Inf"`, value: float64(h.count)},
+			sample{suffix: "_sum", labels: labels, value: h.sum},
+			sample{suffix: "_count", labels: labels, value: float64(h.count)},
+		)
+	}
+	return samples
+}
+
+// failureSamples returns one sample per target and SQLSTATE
+func (r *Recorder) failureSamples() []sample {
+	samples := make([]sample, 0, len(r.failures))
+	for key, value := range r.failures {
+		labels := labelPairs("database", key.database, "environment", key.environment, "sqlstate", key.sqlstate)
+		samples = append(samples, sample{labels: labels, value: value})
+	}
+	return samples
+}
+
+// writeFamily writes the HELP and TYPE lines and the samples of a metric
+// Families without samples are left out
+func writeFamily(buf *bytes.Buffer, name, help, kind string, samples []sample) {
+	if len(samples) == 0 {
+		return
+	}
+
+	// Histogram buckets keep their order; everything else sorts by labels
+	sort.SliceStable(samples, func(i, j int) bool {
+		return histogramSeries(samples[i].labels) < histogramSeries(samples[j].labels)
+	})
+
+	fmt.Fprintf(buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, kind)
+	for _, s := range samples {
+		fmt.Fprintf(buf, "%s%s{%s} %s\n", name, s.suffix, s.labels, strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+}
+
+// histogramSeries strips the le label so a histogram's lines sort together
+func histogramSeries(labels string) string {
+	if i := strings.Index(labels, `,le="`); i >= 0 {
+		return labels[:i]
+	}
+	return labels
+}
+
+// labels returns the label pairs of t
+func (t target) labels() string {
+	return labelPairs("database", t.database, "environment", t.environment)
+}
+
+// labelPairs formats alternating names and values as name="value" pairs
+func labelPairs(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+// escapeLabel escapes a label value as the text format requires
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// observer records the events of runs against a single target
+type observer struct {
+	recorder *Recorder
+	target   target
+	waiting  time.Duration // Current wait for the migration lock
+}
+
+func (o *observer) OnPlan(event migration.PlanEvent) {
+	if event.Direction == migration.DirectionUp {
+		o.recorder.SetPending(o.target.environment, o.target.database, event.Pending)
+	}
+}
+
+func (o *observer) OnMigrationStart(event migration.MigrationEvent) {}
+
+func (o *observer) OnStatement(event migration.StatementEvent) {}
+
+func (o *observer) OnMigrationDone(event migration.MigrationEvent) {
+	o.recorder.add(o.recorder.applied, o.target, 1)
+	o.recorder.adjustPending(o.target, -1)
+	o.recorder.observe(durationKey{o.target, event.Migration.Name, event.Direction}, event.Duration)
+}
+
+func (o *observer) OnRollback(event migration.MigrationEvent) {
+	o.recorder.add(o.recorder.rolledBack, o.target, 1)
+	o.recorder.adjustPending(o.target, 1)
+	o.recorder.observe(durationKey{o.target, event.Migration.Name, event.Direction}, event.Duration)
+}
+
+// OnError counts the failure by SQLSTATE; a lock timeout also counts the time waited
+func (o *observer) OnError(event migration.ErrorEvent) {
+	sqlstate := "none"
+	var migrationErr *errors.MigrationError
+	if stderrors.As(event.Err, &migrationErr) && migrationErr.SQLState != "" {
+		sqlstate = migrationErr.SQLState
+	}
+
+	o.recorder.mu.Lock()
+	o.recorder.failures[failureKey{o.target, sqlstate}]++
+	o.recorder.mu.Unlock()
+
+	if stderrors.Is(event.Err, errors.ErrLockTimeout) {
+		o.recorder.add(o.recorder.lockWait, o.target, o.waiting.Seconds())
+		o.waiting = 0
+	}
+}
+
+func (o *observer) OnLockWait(event migration.LockEvent) {
+	if event.Acquired {
+		o.recorder.add(o.recorder.lockWait, o.target, event.Waited.Seconds())
+		o.waiting = 0
+		return
+	}
+	o.waiting = event.Waited
+}
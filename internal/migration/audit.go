@@ -0,0 +1,191 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vorzela/vorm/internal/buildinfo"
+	"github.com/vorzela/vorm/internal/utils"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// AuditAction is the kind of change recorded in the audit table
+type AuditAction string
+
+// Audit actions
+const (
+	AuditUp             AuditAction = "up"
+	AuditDown           AuditAction = "down"
+	AuditBaseline       AuditAction = "baseline"
+	AuditChecksumAccept AuditAction = "checksum-accept"
+	AuditRepair         AuditAction = "repair"
+	AuditFake           AuditAction = "fake"
+)
+
+// AuditActions lists every valid action
+var AuditActions = []AuditAction{AuditUp, AuditDown, AuditBaseline, AuditChecksumAccept, AuditRepair, AuditFake}
+
+// Audit outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEntry is a single row of the audit table
+type AuditEntry struct {
+	ID          int64       `json:"id" yaml:"id"`
+	Action      AuditAction `json:"action" yaml:"action"`
+	Migration   string      `json:"migration" yaml:"migration"`
+	Batch       int         `json:"batch" yaml:"batch"`
+	Checksum    string      `json:"checksum" yaml:"checksum"`
+	RunID       string      `json:"run_id" yaml:"run_id"`
+	User        string      `json:"user" yaml:"user"`
+	Hostname    string      `json:"hostname" yaml:"hostname"`
+	Version     string      `json:"version" yaml:"version"`
+	Commit      string      `json:"commit" yaml:"commit"`
+	Environment string      `json:"environment" yaml:"environment"`
+	Duration    int         `json:"duration" yaml:"duration"` // milliseconds
+	Outcome     string      `json:"outcome" yaml:"outcome"`
+	Error       string      `json:"error,omitempty" yaml:"error,omitempty"`
+	CreatedAt   time.Time   `json:"created_at" yaml:"created_at"`
+}
+
+// AuditFilter selects audit entries; zero values match everything
+type AuditFilter struct {
+	Since     time.Time
+	Until     time.Time
+	Migration string
+	Action    AuditAction
+	Limit     int
+}
+
+// ParseAuditAction validates an action name
+func ParseAuditAction(value string) (AuditAction, error) {
+	for _, action := range AuditActions {
+		if string(action) == value {
+			return action, nil
+		}
+	}
+
+	names := make([]string, len(AuditActions))
+	for i, action := range AuditActions {
+		names[i] = string(action)
+	}
+	return "", errors.NewValidationError("Invalid audit action",
+		fmt.Sprintf("action must be one of: %s. Got: %s", strings.Join(names, ", "), value))
+}
+
+// RecordAudit appends an entry for action on migration to the audit table
+// It runs outside the migration's transaction, so failed attempts are kept
+func (t *Tracker) RecordAudit(ctx context.Context, action AuditAction, migration *Migration, batch int, duration time.Duration, actionErr error) error {
+	outcome, message := AuditSuccess, ""
+	if actionErr != nil {
+		outcome, message = AuditFailure, actionErr.Error()
+	}
+
+	sql := fmt.Sprintf(`
+		INSERT INTO %s (action, migration, batch, checksum, run_id, os_user, hostname,
+			vorm_version, vorm_commit, environment, duration, outcome, error)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12, NULLIF($13, ''))
+	`, pgx.Identifier{t.config.GetAuditTable()}.Sanitize())
+
+	_, err := t.conn.Conn().Exec(ctx, sql,
+		string(action),
+		migration.Name,
+		batch,
+		migration.Checksum,
+		t.runID,
+		utils.GetUsername(),
+		utils.GetHostname(),
+		buildinfo.Version,
+		buildinfo.Commit,
+		t.config.Environment,
+		int(duration.Milliseconds()),
+		outcome,
+		message,
+	)
+	if err != nil {
+		return errors.NewMigrationError("Failed to record audit entry", err.Error(), migration.Name).WithCause(err)
+	}
+
+	return nil
+}
+
+// GetAuditEntries returns audit entries matching filter, newest first
+func (t *Tracker) GetAuditEntries(ctx context.Context, filter AuditFilter) ([]*AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if !filter.Since.IsZero() {
+		addCondition("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		addCondition("created_at < $%d", filter.Until)
+	}
+	if filter.Migration != "" {
+		addCondition("migration = $%d", filter.Migration)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", string(filter.Action))
+	}
+
+	sql := fmt.Sprintf(`
+		SELECT id, action, migration, COALESCE(batch, 0), COALESCE(checksum, ''), COALESCE(run_id, ''),
+			COALESCE(os_user, ''), COALESCE(hostname, ''), COALESCE(vorm_version, ''), COALESCE(vorm_commit, ''),
+			COALESCE(environment, ''), duration, outcome, COALESCE(error, ''), created_at
+		FROM %s
+	`, pgx.Identifier{t.config.GetAuditTable()}.Sanitize())
+	if len(conditions) > 0 {
+		sql += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	sql += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		sql += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := t.conn.Conn().Query(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.NewMigrationError("Failed to get audit entries", err.Error(), "").WithCause(err)
+	}
+	defer rows.Close()
+
+	var entries []*AuditEntry
+	for rows.Next() {
+		entry := &AuditEntry{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Action,
+			&entry.Migration,
+			&entry.Batch,
+			&entry.Checksum,
+			&entry.RunID,
+			&entry.User,
+			&entry.Hostname,
+			&entry.Version,
+			&entry.Commit,
+			&entry.Environment,
+			&entry.Duration,
+			&entry.Outcome,
+			&entry.Error,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.NewMigrationError("Failed to scan audit row", err.Error(), "").WithCause(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewMigrationError("Error reading audit entries", err.Error(), "").WithCause(err)
+	}
+
+	return entries, nil
+}
@@ -2,39 +2,62 @@ package migration
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/vorzela/vorm/internal/config"
 	"github.com/vorzela/vorm/internal/database"
 	"github.com/vorzela/vorm/internal/logger"
+	"github.com/vorzela/vorm/internal/tracing"
 	"github.com/vorzela/vorm/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Executor handles migration execution
 type Executor struct {
-	config  *config.Config
-	conn    *database.Connection
-	tracker *Tracker
-	logger  *logger.Logger
+	config    *config.Config
+	conn      *database.Connection
+	tracker   *Tracker
+	hooks     *HookRunner
+	observers observers
+	tracer    trace.Tracer
+	logger    logger.Logger
 }
 
 // NewExecutor creates a new migration executor
-func NewExecutor(cfg *config.Config, conn *database.Connection, logger *logger.Logger) *Executor {
-	tracker := NewTracker(cfg, conn)
+func NewExecutor(cfg *config.Config, conn *database.Connection, logger logger.Logger) *Executor {
+	tracker := NewTracker(cfg, conn, logger)
 	return &Executor{
-		config:  cfg,
-		conn:    conn,
-		tracker: tracker,
-		logger:  logger,
+		config:    cfg,
+		conn:      conn,
+		tracker:   tracker,
+		hooks:     NewHookRunner(cfg, conn, logger),
+		observers: observers{newLogObserver(logger)},
+		tracer:    tracing.Tracer(nil),
+		logger:    logger,
 	}
 }
 
+// AddObserver registers an observer for migration progress events
+func (e *Executor) AddObserver(observer Observer) {
+	e.observers = append(e.observers, observer)
+}
+
+// SetRunID sets the run identifier recorded with migrations and passed to hooks
+func (e *Executor) SetRunID(runID string) {
+	e.tracker.SetRunID(runID)
+	e.hooks.SetRunID(runID)
+}
+
 // RunMigrations executes pending migrations
 func (e *Executor) RunMigrations(ctx context.Context, migrations []*Migration, limit int) error {
 	if len(migrations) == 0 {
+		e.observers.plan(PlanEvent{Direction: DirectionUp})
 		e.logger.Info("Migration", "No pending migrations to run")
 		return nil
 	}
@@ -45,28 +68,59 @@ func (e *Executor) RunMigrations(ctx context.Context, migrations []*Migration, l
 		return err
 	}
 	nextBatch := lastBatch + 1
+	pending := len(migrations)
 
 	// Limit migrations if specified
 	if limit > 0 && limit < len(migrations) {
 		migrations = migrations[:limit]
 	}
 
-	e.logger.Info("Migration", fmt.Sprintf("Running %d migrations in batch %d", len(migrations), nextBatch))
+	e.observers.plan(PlanEvent{Direction: DirectionUp, Batch: nextBatch, Pending: pending, Migrations: migrations})
 
-	for _, migration := range migrations {
-		if err := e.runSingleMigration(ctx, migration, nextBatch); err != nil {
-			e.logger.LogMigrationError(migration.Name, err)
-			return err
+	run := HookContext{Direction: DirectionUp, Batch: nextBatch, Count: len(migrations)}
+	if err := e.runHooks(ctx, HookBeforeMigrate, run); err != nil {
+		return e.failed(ctx, run, interrupted(ctx, err, migrations, 0))
+	}
+
+	runStart := time.Now()
+	for i, migration := range migrations {
+		// No migration starts once the run is cancelled
+		run.Migration, run.Duration = migration, 0
+		if err := ctx.Err(); err != nil {
+			return e.failed(ctx, run, interrupted(ctx, err, migrations, i))
+		}
+		if err := e.runHooks(ctx, HookBeforeEach, run); err != nil {
+			return e.failed(ctx, run, interrupted(ctx, err, migrations, i))
+		}
+
+		start := time.Now()
+		err := e.runSingleMigration(ctx, migration, nextBatch)
+		e.audit(ctx, AuditUp, migration, nextBatch, time.Since(start), err)
+		if err != nil {
+			return e.failed(ctx, run, interrupted(ctx, err, migrations, i))
+		}
+
+		run.Duration = time.Since(start)
+		if err := e.runHooks(ctx, HookAfterEach, run); err != nil {
+			return e.failed(ctx, run, interrupted(ctx, err, migrations, i+1))
 		}
 	}
 
 	e.logger.Success("Migration", fmt.Sprintf("Successfully ran %d migrations", len(migrations)))
+
+	run.Migration, run.Duration = nil, time.Since(runStart)
+	if err := e.runHooks(ctx, HookAfterMigrate, run); err != nil {
+		return e.failed(ctx, run, interrupted(ctx, err, migrations, len(migrations)))
+	}
 	return nil
 }
 
 // runSingleMigration executes a single migration
-func (e *Executor) runSingleMigration(ctx context.Context, migration *Migration, batch int) error {
-	e.logger.LogMigrationStart(migration.Name)
+func (e *Executor) runSingleMigration(ctx context.Context, migration *Migration, batch int) (err error) {
+	ctx, span := e.startMigrationSpan(ctx, migration, DirectionUp, batch)
+	defer func() { tracing.End(span, err) }()
+
+	e.observers.migrationStart(MigrationEvent{Migration: migration, Direction: DirectionUp, Batch: batch})
 
 	// Start timing
 	start := time.Now()
@@ -74,12 +128,17 @@ func (e *Executor) runSingleMigration(ctx context.Context, migration *Migration,
 	// Begin transaction for atomic migration
 	tx, err := e.conn.Begin(ctx)
 	if err != nil {
-		return errors.NewMigrationError("Failed to begin transaction", err.Error(), migration.Name)
+		return errors.NewMigrationError("Failed to begin transaction", err.Error(), migration.Name).WithCause(err)
 	}
-	defer tx.Rollback(ctx)
+	// Roll back even if ctx was cancelled, so the connection stays usable
+	defer tx.Rollback(context.WithoutCancel(ctx))
 
-	// Execute migration SQL
-	if err := e.executeMigrationSQL(ctx, tx, migration.UpSQL, migration.Name); err != nil {
+	// Execute migration SQL, or the function of a Go migration
+	if migration.Up != nil {
+		if err := e.executeMigrationFunc(ctx, tx, migration, migration.Up); err != nil {
+			return err
+		}
+	} else if err := e.executeMigrationSQL(ctx, tx, migration, DirectionUp, migration.UpSQL, migration.UpLine); err != nil {
 		return err
 	}
 
@@ -89,18 +148,22 @@ func (e *Executor) runSingleMigration(ctx context.Context, migration *Migration,
 		return err
 	}
 
-	// Commit transaction
+	// Commit transaction unless the run was cancelled meanwhile
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if err := tx.Commit(ctx); err != nil {
-		return errors.NewMigrationError("Failed to commit migration", err.Error(), migration.Name)
+		return errors.NewMigrationError("Failed to commit migration", err.Error(), migration.Name).WithCause(err)
 	}
 
-	e.logger.LogMigrationSuccess(migration.Name, executionTime)
+	e.observers.migrationDone(MigrationEvent{Migration: migration, Direction: DirectionUp, Batch: batch, Duration: executionTime})
 	return nil
 }
 
 // RollbackMigrations rolls back migrations
 func (e *Executor) RollbackMigrations(ctx context.Context, migrations []*Migration, limit int) error {
 	if len(migrations) == 0 {
+		e.observers.plan(PlanEvent{Direction: DirectionDown})
 		e.logger.Info("Migration", "No migrations to rollback")
 		return nil
 	}
@@ -110,32 +173,125 @@ func (e *Executor) RollbackMigrations(ctx context.Context, migrations []*Migrati
 		migrations = migrations[:limit]
 	}
 
-	e.logger.Warning("Migration", fmt.Sprintf("Rolling back %d migrations", len(migrations)))
+	e.observers.plan(PlanEvent{Direction: DirectionDown, Migrations: migrations})
 
-	for _, migration := range migrations {
-		if err := e.rollbackSingleMigration(ctx, migration); err != nil {
-			e.logger.LogMigrationError(migration.Name, err)
-			return err
+	run := HookContext{Direction: DirectionDown, Count: len(migrations)}
+	if err := e.runHooks(ctx, HookBeforeMigrate, run); err != nil {
+		return e.failed(ctx, run, interrupted(ctx, err, migrations, 0))
+	}
+
+	runStart := time.Now()
+	for i, migration := range migrations {
+		// No rollback starts once the run is cancelled
+		run.Migration, run.Batch, run.Duration = migration, migration.Batch, 0
+		if err := ctx.Err(); err != nil {
+			return e.failed(ctx, run, interrupted(ctx, err, migrations, i))
+		}
+		if err := e.runHooks(ctx, HookBeforeEach, run); err != nil {
+			return e.failed(ctx, run, interrupted(ctx, err, migrations, i))
+		}
+
+		start := time.Now()
+		err := e.rollbackSingleMigration(ctx, migration)
+		e.audit(ctx, AuditDown, migration, migration.Batch, time.Since(start), err)
+		if err != nil {
+			return e.failed(ctx, run, interrupted(ctx, err, migrations, i))
+		}
+
+		run.Duration = time.Since(start)
+		if err := e.runHooks(ctx, HookAfterEach, run); err != nil {
+			return e.failed(ctx, run, interrupted(ctx, err, migrations, i+1))
 		}
 	}
 
 	e.logger.Success("Migration", fmt.Sprintf("Successfully rolled back %d migrations", len(migrations)))
+
+	// A rollback may span batches, so run-level hooks get no batch
+	run.Migration, run.Batch, run.Duration = nil, 0, time.Since(runStart)
+	if err := e.runHooks(ctx, HookAfterMigrate, run); err != nil {
+		return e.failed(ctx, run, interrupted(ctx, err, migrations, len(migrations)))
+	}
 	return nil
 }
 
+// runHooks runs the hooks configured for event with the run's metadata
+func (e *Executor) runHooks(ctx context.Context, event HookEvent, run HookContext) error {
+	run.Event = event
+	return e.hooks.Run(ctx, run)
+}
+
+// failed reports err to observers, runs the on_failure hooks and returns err
+// The hooks also run when the run was cancelled, bounded by their own timeouts
+func (e *Executor) failed(ctx context.Context, run HookContext, err error) error {
+	e.observers.error(ErrorEvent{Migration: run.Migration, Direction: run.Direction, Batch: run.Batch, Err: err})
+
+	run.Event, run.Err = HookOnFailure, err
+	e.hooks.Run(context.WithoutCancel(ctx), run)
+	return err
+}
+
+// maxListedMigrations caps the migration names listed in an interrupted error
+const maxListedMigrations = 10
+
+// interrupted turns err into an interrupted error if ctx was cancelled,
+// listing the first completed migrations of the run and the ones left
+// Otherwise err is returned as is
+func interrupted(ctx context.Context, err error, migrations []*Migration, completed int) error {
+	if ctx.Err() == nil || stderrors.Is(err, errors.ErrInterrupted) {
+		return err
+	}
+
+	reason := "cancelled"
+	if stderrors.Is(ctx.Err(), context.DeadlineExceeded) {
+		reason = "deadline exceeded"
+	}
+
+	details := fmt.Sprintf("%s after %d of %d migrations; completed: %s; remaining: %s", reason,
+		completed, len(migrations), migrationNames(migrations[:completed]), migrationNames(migrations[completed:]))
+	return errors.NewInterruptedError("Migration run interrupted", details).WithCause(ctx.Err())
+}
+
+// migrationNames lists the names of migrations for a message
+func migrationNames(migrations []*Migration) string {
+	if len(migrations) == 0 {
+		return "none"
+	}
+
+	var names []string
+	for i, migration := range migrations {
+		if i == maxListedMigrations {
+			names = append(names, fmt.Sprintf("and %d more", len(migrations)-i))
+			break
+		}
+		names = append(names, migration.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
 // rollbackSingleMigration rolls back a single migration
-func (e *Executor) rollbackSingleMigration(ctx context.Context, migration *Migration) error {
-	e.logger.LogRollbackStart(migration.Name)
+func (e *Executor) rollbackSingleMigration(ctx context.Context, migration *Migration) (err error) {
+	ctx, span := e.startMigrationSpan(ctx, migration, DirectionDown, migration.Batch)
+	defer func() { tracing.End(span, err) }()
+
+	e.observers.migrationStart(MigrationEvent{Migration: migration, Direction: DirectionDown, Batch: migration.Batch})
+
+	// Start timing
+	start := time.Now()
 
 	// Begin transaction for atomic rollback
 	tx, err := e.conn.Begin(ctx)
 	if err != nil {
-		return errors.NewMigrationError("Failed to begin transaction", err.Error(), migration.Name)
+		return errors.NewMigrationError("Failed to begin transaction", err.Error(), migration.Name).WithCause(err)
 	}
-	defer tx.Rollback(ctx)
+	// Roll back even if ctx was cancelled, so the connection stays usable
+	defer tx.Rollback(context.WithoutCancel(ctx))
 
-	// Execute rollback SQL
-	if err := e.executeMigrationSQL(ctx, tx, migration.DownSQL, migration.Name); err != nil {
+	// Execute rollback SQL, or the function of a Go migration
+	if migration.Up != nil {
+		if err := e.executeMigrationFunc(ctx, tx, migration, migration.Down); err != nil {
+			return err
+		}
+	} else if err := e.executeMigrationSQL(ctx, tx, migration, DirectionDown, migration.DownSQL, migration.DownLine); err != nil {
 		return err
 	}
 
@@ -144,64 +300,112 @@ func (e *Executor) rollbackSingleMigration(ctx context.Context, migration *Migra
 		return err
 	}
 
-	// Commit transaction
+	// Commit transaction unless the run was cancelled meanwhile
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if err := tx.Commit(ctx); err != nil {
-		return errors.NewMigrationError("Failed to commit rollback", err.Error(), migration.Name)
+		return errors.NewMigrationError("Failed to commit rollback", err.Error(), migration.Name).WithCause(err)
 	}
 
-	e.logger.LogRollbackSuccess(migration.Name)
+	e.observers.rollback(MigrationEvent{Migration: migration, Direction: DirectionDown, Batch: migration.Batch, Duration: time.Since(start)})
 	return nil
 }
 
-// executeMigrationSQL executes SQL statements within a transaction
-func (e *Executor) executeMigrationSQL(ctx context.Context, tx pgx.Tx, sql, migrationName string) error {
-	// Skip empty SQL
-	sql = strings.TrimSpace(sql)
-	if sql == "" {
-		return nil
+// executeMigrationFunc runs a Go migration's function within a transaction
+// A Go migration registered without a down function can't be rolled back
+func (e *Executor) executeMigrationFunc(ctx context.Context, tx pgx.Tx, migration *Migration, fn MigrationFunc) error {
+	if fn == nil {
+		return errors.NewMigrationError("Migration can't be rolled back",
+			"it was registered without a down function", migration.Name)
+	}
+	if err := fn(ctx, tx); err != nil {
+		return errors.NewMigrationError("Migration function failed", err.Error(), migration.Name).WithCause(err)
 	}
+	return nil
+}
 
-	// Split SQL by statements (basic implementation)
-	statements := e.splitSQLStatements(sql)
+// executeMigrationSQL executes SQL statements within a transaction
+// startLine is the file line sql begins on, used to locate failures
+func (e *Executor) executeMigrationSQL(ctx context.Context, tx pgx.Tx, migration *Migration, direction, sql string, startLine int) error {
+	statements := SplitStatements(sql, startLine)
 
 	for i, statement := range statements {
-		statement = strings.TrimSpace(statement)
-		if statement == "" {
-			continue
-		}
-
-		// Skip comments
-		if strings.HasPrefix(statement, "--") {
-			continue
-		}
-
-		if _, err := tx.Exec(ctx, statement); err != nil {
-			return errors.NewMigrationError(
+		stmtCtx, span := e.startStatementSpan(ctx, migration, i+1, statement)
+		start := time.Now()
+		if _, err := database.ExecCancellable(stmtCtx, tx.Conn(), statement.SQL); err != nil {
+			migrationErr := errors.NewMigrationError(
 				fmt.Sprintf("Failed to execute SQL statement %d", i+1),
 				err.Error(),
-				migrationName,
-			)
+				migration.Name,
+			).WithCause(err)
+			migrationErr.Statement = i + 1
+			migrationErr.Location = statementLocation(migration.Filepath, sql, startLine, statement, migrationErr.PgError())
+			tracing.End(span, migrationErr)
+			return migrationErr
 		}
+		span.End()
+
+		e.observers.statement(StatementEvent{
+			Migration: migration,
+			Direction: direction,
+			Index:     i + 1,
+			Total:     len(statements),
+			Statement: statement,
+			Duration:  time.Since(start),
+		})
 	}
 
 	return nil
 }
 
-// splitSQLStatements splits SQL into individual statements
-// This is a basic implementation - more sophisticated parsing might be needed
-func (e *Executor) splitSQLStatements(sql string) []string {
-	// Split on semicolons, but be careful about semicolons in strings
-	statements := strings.Split(sql, ";")
-
-	var result []string
-	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt != "" {
-			result = append(result, stmt)
+// statementLocation maps a failed statement, and the error position reported
+// by PostgreSQL if any, to a location in the migration file
+// sql is the section the statement was split from, starting at startLine
+func statementLocation(file, sql string, startLine int, statement Statement, pgErr *pgconn.PgError) *errors.Location {
+	location := &errors.Location{
+		File: file,
+		Line: statement.Line,
+	}
+
+	// Position is a 1-based character offset into the statement
+	if pgErr != nil && pgErr.Position > 0 {
+		lines := strings.Split(statement.SQL, "\n")
+		offset := int(pgErr.Position) - 1
+		index := 0
+		for index < len(lines)-1 && offset > utf8.RuneCountInString(lines[index]) {
+			offset -= utf8.RuneCountInString(lines[index]) + 1
+			index++
+		}
+		location.Line = statement.Line + index
+		location.Column = offset + 1
+		if index == 0 {
+			location.Column += statement.Column - 1
 		}
 	}
 
-	return result
+	// Show up to three file lines of the statement ending at the error line
+	sourceLines := strings.Split(sql, "\n")
+	first := location.Line - 2
+	if first < statement.Line {
+		first = statement.Line
+	}
+	for line := first; line <= location.Line; line++ {
+		if i := line - startLine; i >= 0 && i < len(sourceLines) {
+			location.Excerpt = append(location.Excerpt, strings.TrimRight(sourceLines[i], "\r"))
+		}
+	}
+
+	return location
+}
+
+// audit records the outcome of an action in the audit table
+// A failure to write the audit entry is logged but doesn't fail the migration
+// Interrupted attempts are recorded too
+func (e *Executor) audit(ctx context.Context, action AuditAction, migration *Migration, batch int, duration time.Duration, actionErr error) {
+	if err := e.tracker.RecordAudit(context.WithoutCancel(ctx), action, migration, batch, duration, actionErr); err != nil {
+		e.logger.Warning("Audit", fmt.Sprintf("Failed to record %s of %s: %v", action, migration.Name, err))
+	}
 }
 
 // RollbackBatch rolls back all migrations from a specific batch
@@ -217,18 +421,10 @@ func (e *Executor) RollbackBatch(ctx context.Context, batch int, allMigrations [
 		return nil
 	}
 
-	// Load migration files to get Down SQL
-	migrationsMap := make(map[string]*Migration)
-	for _, migration := range allMigrations {
-		migrationsMap[migration.Name] = migration
-	}
-
-	// Prepare migrations for rollback with Down SQL
-	var migrationsToRollback []*Migration
-	for _, batchMigration := range batchMigrations {
-		if fullMigration, exists := migrationsMap[batchMigration.Name]; exists {
-			migrationsToRollback = append(migrationsToRollback, fullMigration)
-		}
+	// Match migration files to get Down SQL
+	migrationsToRollback, err := matchMigrationFiles(batchMigrations, allMigrations)
+	if err != nil {
+		return err
 	}
 
 	return e.RollbackMigrations(ctx, migrationsToRollback, 0)
@@ -253,18 +449,10 @@ func (e *Executor) ResetAllMigrations(ctx context.Context, allMigrations []*Migr
 		executedMigrations[i], executedMigrations[opp] = executedMigrations[opp], executedMigrations[i]
 	}
 
-	// Load migration files to get Down SQL
-	migrationsMap := make(map[string]*Migration)
-	for _, migration := range allMigrations {
-		migrationsMap[migration.Name] = migration
-	}
-
-	// Prepare migrations for rollback with Down SQL
-	var migrationsToRollback []*Migration
-	for _, executedMigration := range executedMigrations {
-		if fullMigration, exists := migrationsMap[executedMigration.Name]; exists {
-			migrationsToRollback = append(migrationsToRollback, fullMigration)
-		}
+	// Match migration files to get Down SQL
+	migrationsToRollback, err := matchMigrationFiles(executedMigrations, allMigrations)
+	if err != nil {
+		return err
 	}
 
 	return e.RollbackMigrations(ctx, migrationsToRollback, 0)
@@ -0,0 +1,174 @@
+package migration
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vorzela/vorm/internal/ddl"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+var (
+	identifierPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	typePattern       = regexp.MustCompile(`^[a-z_][a-z0-9_ ]*(\([0-9, ]+\))?(\[\])*$`)
+)
+
+// Field is a column given to make:migration with --fields
+type Field struct {
+	Name       string
+	Type       string   // SQL type, or the name of the enum type created for the column
+	Enum       []string // Values of an enum(a,b) column
+	NotNull    bool
+	Unique     bool
+	Index      bool
+	Default    string // SQL expression
+	References string // table(column) of a foreign key
+}
+
+// ParseFields parses a --fields specification such as
+// "user_id:uuid:fk=users,total:numeric(12,2):notnull,status:enum(pending,paid):index"
+// Each field is name:type followed by modifiers: notnull, unique, index,
+// default=<expr> and fk=<table>[.<column>]
+func ParseFields(spec string) ([]Field, error) {
+	var fields []Field
+	seen := make(map[string]bool)
+	for _, definition := range splitTopLevel(spec, ',') {
+		definition = strings.TrimSpace(definition)
+		if definition == "" {
+			continue
+		}
+
+		parts := splitTopLevel(definition, ':')
+		if len(parts) < 2 {
+			return nil, errors.NewValidationError("Invalid field", fmt.Sprintf("%q: use name:type[:modifier...]", definition))
+		}
+
+		field := Field{Name: strings.TrimSpace(parts[0])}
+		if !identifierPattern.MatchString(field.Name) {
+			return nil, errors.NewValidationError("Invalid field name", fmt.Sprintf("%q: use lowercase letters, digits and underscores", field.Name))
+		}
+		if seen[field.Name] {
+			return nil, errors.NewValidationError("Duplicate field", field.Name)
+		}
+		seen[field.Name] = true
+
+		if err := field.parseType(strings.TrimSpace(parts[1])); err != nil {
+			return nil, err
+		}
+		for _, modifier := range parts[2:] {
+			if err := field.parseModifier(strings.TrimSpace(modifier)); err != nil {
+				return nil, err
+			}
+		}
+		fields = append(fields, field)
+	}
+
+	if len(fields) == 0 {
+		return nil, errors.NewValidationError("No fields given", "use --fields name:type[:modifier...],...")
+	}
+	return fields, nil
+}
+
+// parseType sets the field's type; enum(a,b) columns get an enum type
+// named after the table and column when the SQL is generated
+func (f *Field) parseType(value string) error {
+	lower := strings.ToLower(value)
+	if strings.HasPrefix(lower, "enum(") && strings.HasSuffix(lower, ")") {
+		for _, label := range splitTopLevel(value[len("enum("):len(value)-1], ',') {
+			label = strings.TrimSpace(label)
+			if len(label) >= 2 && strings.HasPrefix(label, "'") && strings.HasSuffix(label, "'") {
+				label = strings.ReplaceAll(label[1:len(label)-1], "''", "'")
+			}
+			if label == "" {
+				return errors.NewValidationError("Invalid enum", fmt.Sprintf("%s: empty value in %s", f.Name, value))
+			}
+			f.Enum = append(f.Enum, label)
+		}
+		if len(f.Enum) == 0 {
+			return errors.NewValidationError("Invalid enum", fmt.Sprintf("%s: enum() needs at least one value", f.Name))
+		}
+		return nil
+	}
+
+	if !typePattern.MatchString(lower) {
+		return errors.NewValidationError("Invalid field type", fmt.Sprintf("%s: %q", f.Name, value))
+	}
+	f.Type = strings.ToUpper(lower)
+	return nil
+}
+
+// parseModifier applies one of the modifiers following the field's type
+func (f *Field) parseModifier(modifier string) error {
+	key, value, hasValue := strings.Cut(modifier, "=")
+	key = strings.ToLower(key)
+	switch key {
+	case "notnull":
+		f.NotNull = true
+	case "unique":
+		f.Unique = true
+	case "index":
+		f.Index = true
+	case "default":
+		if value == "" {
+			return errors.NewValidationError("Invalid field modifier", fmt.Sprintf("%s: default needs a value, e.g. default=0", f.Name))
+		}
+		f.Default = value
+	case "fk":
+		table, column, _ := strings.Cut(value, ".")
+		if column == "" {
+			column = "id"
+		}
+		if !identifierPattern.MatchString(table) || !identifierPattern.MatchString(column) {
+			return errors.NewValidationError("Invalid field modifier", fmt.Sprintf("%s: use fk=<table> or fk=<table>.<column>", f.Name))
+		}
+		f.References = fmt.Sprintf("%s(%s)", table, column)
+	default:
+		return errors.NewValidationError("Invalid field modifier",
+			fmt.Sprintf("%s: unknown modifier %q; use notnull, unique, index, default=<expr> or fk=<table>", f.Name, modifier))
+	}
+
+	if hasValue && key != "default" && key != "fk" {
+		return errors.NewValidationError("Invalid field modifier", fmt.Sprintf("%s: %s takes no value", f.Name, key))
+	}
+	return nil
+}
+
+// column returns the field as a column of the DDL generator
+func (f *Field) column() ddl.Column {
+	return ddl.Column{
+		Name:       f.Name,
+		Type:       f.Type,
+		Enum:       f.Enum,
+		NotNull:    f.NotNull,
+		Unique:     f.Unique,
+		Index:      f.Index,
+		Default:    f.Default,
+		References: f.References,
+	}
+}
+
+// splitTopLevel splits s on sep outside parentheses and single quotes
+func splitTopLevel(s string, sep rune) []string {
+	var (
+		parts  []string
+		depth  int
+		quoted bool
+		start  int
+	)
+	for i, r := range s {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
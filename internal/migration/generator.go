@@ -3,60 +3,87 @@ package migration
 import (
 	"crypto/sha256"
 	"fmt"
-	"io/fs"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/ddl"
 	"github.com/vorzela/vorm/internal/utils"
 	"github.com/vorzela/vorm/pkg/errors"
 )
 
 // Migration represents a single database migration
 type Migration struct {
-	ID            int       `json:"id"`
-	Name          string    `json:"name"`
-	Filename      string    `json:"filename"`
-	Filepath      string    `json:"filepath"`
-	Batch         int       `json:"batch"`
-	ExecutedAt    time.Time `json:"executed_at"`
-	ExecutionTime int       `json:"execution_time"` // milliseconds
-	Checksum      string    `json:"checksum"`
-	UpSQL         string    `json:"up_sql"`
-	DownSQL       string    `json:"down_sql"`
+	ID            int           `json:"id" yaml:"id"`
+	Name          string        `json:"name" yaml:"name"`
+	Filename      string        `json:"filename" yaml:"filename"`
+	Filepath      string        `json:"filepath" yaml:"filepath"`
+	Batch         int           `json:"batch" yaml:"batch"`
+	ExecutedAt    time.Time     `json:"executed_at" yaml:"executed_at"`
+	ExecutionTime int           `json:"execution_time" yaml:"execution_time"` // milliseconds
+	Checksum      string        `json:"checksum" yaml:"checksum"`
+	RunID         string        `json:"run_id,omitempty" yaml:"run_id,omitempty"` // Run that applied the migration
+	UpSQL         string        `json:"up_sql" yaml:"up_sql"`
+	DownSQL       string        `json:"down_sql" yaml:"down_sql"`
+	UpLine        int           `json:"-" yaml:"-"` // File line the Up section starts on
+	DownLine      int           `json:"-" yaml:"-"` // File line the Down section starts on
+	Up            MigrationFunc `json:"-" yaml:"-"` // Go migrations run this instead of UpSQL
+	Down          MigrationFunc `json:"-" yaml:"-"` // Nil if a Go migration can't be rolled back
 }
 
 // Generator handles migration file generation
 type Generator struct {
-	config *config.Config
+	config     *config.Config
+	source     Source
+	seeders    Source
+	registered []*Migration // Migrations defined in code
 }
 
 // NewGenerator creates a new migration generator
 func NewGenerator(cfg *config.Config) *Generator {
 	return &Generator{
-		config: cfg,
+		config:  cfg,
+		source:  NewSourceFromConfig(cfg),
+		seeders: NewDirSource(cfg.GetSeedersPath()),
 	}
 }
 
+// SetSource replaces the source migrations are loaded from
+func (g *Generator) SetSource(source Source) {
+	g.source = source
+}
+
+// Source returns the source migrations are loaded from
+func (g *Generator) Source() Source {
+	return g.source
+}
+
 // GenerateMigration creates a new migration file
-func (g *Generator) GenerateMigration(name string) (*Migration, error) {
+// With fields, create_X_table and add_X_to_Y_table migrations get the
+// columns, constraints and indexes in Up and their inverse in Down
+func (g *Generator) GenerateMigration(name string, fields ...Field) (*Migration, error) {
 	// Validate and sanitize migration name
 	if !utils.ValidateMigrationName(name) {
 		name = utils.SanitizeMigrationName(name)
 	}
 
+	if len(fields) > 0 && !strings.HasPrefix(name, "create_") && g.extractTableNameFromAdd(name) == "" {
+		return nil, errors.NewValidationError("Fields need a table migration",
+			fmt.Sprintf("%s: name it create_<table>_table or add_<columns>_to_<table>_table", name))
+	}
+
 	// Generate migration filename
 	filename := utils.GenerateMigrationFilename(name)
 	filepath := filepath.Join(g.config.GetMigrationsPath(), filename)
 
 	// Create migration content
-	content := g.generateMigrationContent(name)
+	content := g.generateMigrationContent(name, fields)
 
 	// Write migration file
 	if err := utils.CreateFile(filepath, content); err != nil {
-		return nil, errors.NewFileError("Failed to create migration file", err.Error())
+		return nil, errors.NewFileError("Failed to create migration file", err.Error()).WithCause(err)
 	}
 
 	// Calculate checksum
@@ -73,7 +100,7 @@ func (g *Generator) GenerateMigration(name string) (*Migration, error) {
 }
 
 // generateMigrationContent creates the migration file content as specified in AINOTES.md
-func (g *Generator) generateMigrationContent(name string) string {
+func (g *Generator) generateMigrationContent(name string, fields []Field) string {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 
 	// Determine table name from migration name for common patterns
@@ -81,7 +108,9 @@ func (g *Generator) generateMigrationContent(name string) string {
 
 	if strings.HasPrefix(name, "create_") {
 		tableName := g.extractTableNameFromCreate(name)
-		upSQL, downSQL = g.generateCreateTableSQL(tableName)
+		upSQL, downSQL = g.generateCreateTableSQL(tableName, fields)
+	} else if len(fields) > 0 {
+		upSQL, downSQL = g.generateAddFieldsSQL(g.extractTableNameFromAdd(name), fields)
 	} else if strings.HasPrefix(name, "add_") {
 		upSQL, downSQL = g.generateAddColumnSQL(name)
 	} else if strings.HasPrefix(name, "drop_") {
@@ -115,24 +144,89 @@ func (g *Generator) extractTableNameFromCreate(name string) string {
 	return tableName
 }
 
+// extractTableNameFromAdd extracts table name from "add_columns_to_tablename_table"
+// pattern, returning "" for other names
+func (g *Generator) extractTableNameFromAdd(name string) string {
+	if !strings.HasPrefix(name, "add_") {
+		return ""
+	}
+
+	index := strings.LastIndex(name, "_to_")
+	if index <= len("add") {
+		return ""
+	}
+	return strings.TrimSuffix(name[index+len("_to_"):], "_table")
+}
+
+// skeletonColumns are the columns every created table starts with
+var skeletonColumns = []ddl.Column{
+	{Name: "id", Type: "UUID", Primary: true, Default: "gen_random_uuid()"},
+	{Name: "created_at", Type: "TIMESTAMP WITH TIME ZONE", Default: "NOW()"},
+	{Name: "updated_at", Type: "TIMESTAMP WITH TIME ZONE", Default: "NOW()"},
+	{Name: "deleted_at", Type: "TIMESTAMP WITH TIME ZONE"},
+}
+
 // generateCreateTableSQL generates CREATE TABLE SQL template
-func (g *Generator) generateCreateTableSQL(tableName string) (upSQL, downSQL string) {
-	upSQL = fmt.Sprintf(`CREATE TABLE %s (
-    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-    deleted_at TIMESTAMP WITH TIME ZONE
-);
+// Fields are added after id; a field named like a skeleton column replaces it
+func (g *Generator) generateCreateTableSQL(tableName string, fields []Field) (upSQL, downSQL string) {
+	given := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		given[field.Name] = true
+	}
+
+	var columns []ddl.Column
+	var indexes []ddl.Index
+	if !given["created_at"] {
+		indexes = append(indexes, ddl.Index{Columns: []string{"created_at"}})
+	}
+	for i, column := range skeletonColumns {
+		if !given[column.Name] {
+			columns = append(columns, column)
+		}
+		if i > 0 {
+			continue
+		}
+		for _, field := range fields {
+			columns = append(columns, field.column())
+			if field.Index {
+				indexes = append(indexes, ddl.Index{Columns: []string{field.Name}})
+			}
+		}
+	}
 
--- Create indexes for performance
-CREATE INDEX idx_%s_created_at ON %s(created_at);`, tableName, tableName, tableName)
+	create := ddl.NewCreateTable(tableName, columns, indexes)
+
+	var up []string
+	if len(create.Types) > 0 {
+		up = append(up, strings.Join(create.Types, "\n")+"\n")
+	}
+	up = append(up, create.Table)
+	if len(create.Indexes) > 0 {
+		up = append(up, "\n-- Create indexes for performance\n"+strings.Join(create.Indexes, "\n"))
+	}
+	upSQL = strings.Join(up, "\n")
 
-	downSQL = fmt.Sprintf(`DROP INDEX IF EXISTS idx_%s_created_at;
-DROP TABLE IF EXISTS %s;`, tableName, tableName)
+	down := ddl.Reversed(create.DropIndexes)
+	down = append(down, create.Drop)
+	down = append(down, ddl.Reversed(create.DropTypes)...)
+	downSQL = strings.Join(down, "\n")
 
 	return upSQL, downSQL
 }
 
+// generateAddFieldsSQL generates ALTER TABLE statements adding fields to a table
+// Down undoes them in reverse order
+func (g *Generator) generateAddFieldsSQL(tableName string, fields []Field) (upSQL, downSQL string) {
+	var up, down []string
+	for _, field := range fields {
+		columnUp, columnDown := ddl.AddColumn(tableName, field.column())
+		up = append(up, columnUp...)
+		down = append(columnDown, down...)
+	}
+
+	return strings.Join(up, "\n"), strings.Join(down, "\n")
+}
+
 // generateAddColumnSQL generates ADD COLUMN SQL template
 func (g *Generator) generateAddColumnSQL(name string) (upSQL, downSQL string) {
 	upSQL = `-- Add your column here
@@ -182,38 +276,25 @@ func (g *Generator) calculateChecksum(content string) string {
 	return fmt.Sprintf("%x", hash)
 }
 
-// LoadMigrations loads all migration files from the migrations directory
+// LoadMigrations loads all migration files from the configured source
 func (g *Generator) LoadMigrations() ([]*Migration, error) {
-	migrationsPath := g.config.GetMigrationsPath()
-
-	// Ensure migrations directory exists
-	if err := utils.EnsureDirectoryExists(migrationsPath); err != nil {
-		return nil, errors.NewFileError("Failed to access migrations directory", err.Error())
+	files, err := g.source.ReadFiles()
+	if err != nil {
+		return nil, err
 	}
 
 	var migrations []*Migration
-
-	err := filepath.WalkDir(migrationsPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip directories and non-SQL files
-		if d.IsDir() || !strings.HasSuffix(path, ".sql") {
-			return nil
-		}
-
-		migration, err := g.loadMigrationFile(path)
+	for _, file := range files {
+		migration, err := g.loadMigrationFile(file)
 		if err != nil {
-			return err
+			return nil, err
 		}
-
 		migrations = append(migrations, migration)
-		return nil
-	})
+	}
 
+	migrations, err = g.mergeRegistered(migrations)
 	if err != nil {
-		return nil, errors.NewFileError("Failed to load migrations", err.Error())
+		return nil, err
 	}
 
 	// Sort migrations by filename (timestamp)
@@ -224,54 +305,52 @@ func (g *Generator) LoadMigrations() ([]*Migration, error) {
 	return migrations, nil
 }
 
-// loadMigrationFile loads a single migration file
-func (g *Generator) loadMigrationFile(filepath string) (*Migration, error) {
-	// Read file content
-	content, err := utils.ReadFile(filepath)
-	if err != nil {
-		return nil, err
-	}
-
+// loadMigrationFile parses a single migration file
+func (g *Generator) loadMigrationFile(file SourceFile) (*Migration, error) {
 	// Parse filename
-	filename := filepath[strings.LastIndex(filepath, "/")+1:]
-	_, name, valid := utils.ParseMigrationFilename(filename)
+	_, name, valid := utils.ParseMigrationFilename(file.Filename)
 	if !valid {
-		return nil, errors.NewValidationError("Invalid migration filename", filename)
+		return nil, errors.NewValidationError("Invalid migration filename", file.Filename)
 	}
 
 	// Parse migration content
-	upSQL, downSQL := g.parseMigrationContent(content)
+	upSQL, downSQL, upLine, downLine := g.parseMigrationContent(file.Content)
 
 	// Calculate checksum
-	checksum := g.calculateChecksum(content)
+	checksum := g.calculateChecksum(file.Content)
 
 	migration := &Migration{
 		Name:     name,
-		Filename: filename,
-		Filepath: filepath,
+		Filename: file.Filename,
+		Filepath: file.Path,
 		Checksum: checksum,
 		UpSQL:    upSQL,
 		DownSQL:  downSQL,
+		UpLine:   upLine,
+		DownLine: downLine,
 	}
 
 	return migration, nil
 }
 
 // parseMigrationContent separates Up and Down SQL from migration file
-func (g *Generator) parseMigrationContent(content string) (upSQL, downSQL string) {
+// It also returns the 1-based file line each section starts on
+func (g *Generator) parseMigrationContent(content string) (upSQL, downSQL string, upLine, downLine int) {
 	lines := strings.Split(content, "\n")
 
 	var upLines, downLines []string
 	var currentSection string
 
-	for _, line := range lines {
+	for i, line := range lines {
 		trimmed := strings.TrimSpace(line)
 
 		if strings.Contains(trimmed, "-- +migrate Up") {
 			currentSection = "up"
+			upLine = i + 2
 			continue
 		} else if strings.Contains(trimmed, "-- +migrate Down") {
 			currentSection = "down"
+			downLine = i + 2
 			continue
 		}
 
@@ -283,5 +362,5 @@ func (g *Generator) parseMigrationContent(content string) (upSQL, downSQL string
 		}
 	}
 
-	return strings.Join(upLines, "\n"), strings.Join(downLines, "\n")
+	return strings.Join(upLines, "\n"), strings.Join(downLines, "\n"), upLine, downLine
 }
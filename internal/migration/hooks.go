@@ -0,0 +1,256 @@
+package migration
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/database"
+	"github.com/vorzela/vorm/internal/logger"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// HookEvent is the point of a run at which hooks fire
+type HookEvent string
+
+// Hook events, matching the keys of the hooks configuration block
+const (
+	HookBeforeMigrate HookEvent = "before_migrate"
+	HookAfterMigrate  HookEvent = "after_migrate"
+	HookBeforeEach    HookEvent = "before_each"
+	HookAfterEach     HookEvent = "after_each"
+	HookOnFailure     HookEvent = "on_failure"
+)
+
+// Migration directions passed to hooks
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+// HookContext is the metadata passed to hooks
+type HookContext struct {
+	Event     HookEvent
+	Direction string     // up or down
+	Migration *Migration // nil for before_migrate and after_migrate
+	Batch     int
+	Count     int           // Number of migrations in the run
+	Duration  time.Duration // Set for after_each and after_migrate
+	Err       error         // Set for on_failure
+}
+
+// HookRunner runs the hooks configured for an event
+type HookRunner struct {
+	config *config.Config
+	conn   *database.Connection
+	logger logger.Logger
+	runID  string
+}
+
+// NewHookRunner creates a new hook runner
+func NewHookRunner(cfg *config.Config, conn *database.Connection, logger logger.Logger) *HookRunner {
+	return &HookRunner{
+		config: cfg,
+		conn:   conn,
+		logger: logger,
+	}
+}
+
+// SetRunID sets the run identifier passed to hooks
+func (h *HookRunner) SetRunID(runID string) {
+	h.runID = runID
+}
+
+// Run runs every hook configured for hc.Event in order
+// A failing hook stops the run unless it sets continue_on_error;
+// on_failure hooks never fail the run since it already failed
+func (h *HookRunner) Run(ctx context.Context, hc HookContext) error {
+	for _, hook := range h.hooks(hc.Event) {
+		if !hook.EnabledIn(h.config.Environment) {
+			continue
+		}
+
+		err := h.runHook(ctx, hook, hc)
+		if err == nil {
+			continue
+		}
+
+		if hook.ContinueOnError || hc.Event == HookOnFailure {
+			h.logger.Warning("Hook", fmt.Sprintf("%s hook '%s' failed: %v", hc.Event, hook.DisplayName(), err))
+			continue
+		}
+
+		migrationName := ""
+		if hc.Migration != nil {
+			migrationName = hc.Migration.Name
+		}
+		return errors.NewMigrationError(
+			fmt.Sprintf("%s hook '%s' failed", hc.Event, hook.DisplayName()),
+			err.Error(), migrationName).WithCause(err)
+	}
+
+	return nil
+}
+
+// hooks returns the hooks configured for event
+func (h *HookRunner) hooks(event HookEvent) []config.HookConfig {
+	hooks := h.config.Hooks
+	switch event {
+	case HookBeforeMigrate:
+		return hooks.BeforeMigrate
+	case HookAfterMigrate:
+		return hooks.AfterMigrate
+	case HookBeforeEach:
+		return hooks.BeforeEach
+	case HookAfterEach:
+		return hooks.AfterEach
+	case HookOnFailure:
+		return hooks.OnFailure
+	}
+	return nil
+}
+
+// runHook runs a single hook, applying its timeout
+func (h *HookRunner) runHook(ctx context.Context, hook config.HookConfig, hc HookContext) error {
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(hook.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	logger.Event(h.logger, logger.INFO, "Hook", fmt.Sprintf("Running %s hook: %s", hc.Event, hook.DisplayName()), h.fields(hc))
+
+	start := time.Now()
+	var err error
+	if hook.SQL != "" {
+		err = h.runSQL(ctx, hook, hc)
+	} else {
+		err = h.runCommand(ctx, hook, hc)
+	}
+	if err != nil {
+		return err
+	}
+
+	h.logger.Debug("Hook", fmt.Sprintf("Finished %s hook: %s (%dms)", hc.Event, hook.DisplayName(), time.Since(start).Milliseconds()))
+	return nil
+}
+
+// runSQL executes a SQL hook file outside any migration transaction
+// The metadata is available to the SQL as current_setting('vorm.<name>', true),
+// e.g. current_setting('vorm.migration', true)
+func (h *HookRunner) runSQL(ctx context.Context, hook config.HookConfig, hc HookContext) error {
+	content, err := os.ReadFile(hook.SQL)
+	if err != nil {
+		return errors.NewFileError(fmt.Sprintf("Failed to read hook SQL file %s", hook.SQL), err.Error()).WithCause(err)
+	}
+
+	// Settings last for the session, so the ones this hook has no value for
+	// are cleared rather than left over from an earlier hook
+	settings := make(map[string]string)
+	for _, key := range optionalEnv {
+		settings[key] = ""
+	}
+	for key, value := range h.env(hc) {
+		settings[key] = value
+	}
+
+	for key, value := range settings {
+		setting := "vorm." + strings.ToLower(strings.TrimPrefix(key, "VORM_"))
+		if err := h.conn.Exec(ctx, `SELECT set_config($1, $2, false)`, setting, value); err != nil {
+			return err
+		}
+	}
+
+	// No arguments, so the file may hold several statements. A timeout or
+	// cancellation cancels them on the server and keeps the connection open
+	if _, err := database.ExecCancellable(ctx, h.conn.Conn(), string(content)); err != nil {
+		return errors.NewMigrationError("Failed to execute SQL", err.Error(), "").WithCause(err)
+	}
+	return nil
+}
+
+// runCommand runs a shell hook and logs its output line by line
+func (h *HookRunner) runCommand(ctx context.Context, hook config.HookConfig, hc HookContext) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", hook.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", hook.Command)
+	}
+
+	// Don't wait on children that outlive a killed shell and hold its output open
+	cmd.WaitDelay = time.Second
+
+	cmd.Env = os.Environ()
+	for key, value := range h.env(hc) {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	output, err := cmd.CombinedOutput()
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			h.logger.Info("Hook", fmt.Sprintf("[%s] %s", hook.DisplayName(), line))
+		}
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %ds", hook.Timeout)
+	}
+	return err
+}
+
+// optionalEnv lists the VORM_* variables env only sets for some hooks
+var optionalEnv = []string{"VORM_BATCH", "VORM_MIGRATION", "VORM_MIGRATION_FILE", "VORM_DURATION_MS", "VORM_ERROR"}
+
+// env returns the metadata passed to hooks as VORM_* environment variables
+func (h *HookRunner) env(hc HookContext) map[string]string {
+	env := map[string]string{
+		"VORM_HOOK":            string(hc.Event),
+		"VORM_DIRECTION":       hc.Direction,
+		"VORM_RUN_ID":          h.runID,
+		"VORM_ENVIRONMENT":     h.config.Environment,
+		"VORM_DATABASE":        h.config.Database.Database,
+		"VORM_MIGRATION_COUNT": strconv.Itoa(hc.Count),
+	}
+
+	if hc.Batch > 0 {
+		env["VORM_BATCH"] = strconv.Itoa(hc.Batch)
+	}
+	if hc.Migration != nil {
+		env["VORM_MIGRATION"] = hc.Migration.Name
+		env["VORM_MIGRATION_FILE"] = hc.Migration.Filename
+	}
+	if hc.Duration > 0 {
+		env["VORM_DURATION_MS"] = strconv.FormatInt(hc.Duration.Milliseconds(), 10)
+	}
+	if hc.Err != nil {
+		env["VORM_ERROR"] = hc.Err.Error()
+	}
+
+	return env
+}
+
+// fields returns the log fields describing hc
+func (h *HookRunner) fields(hc HookContext) logger.Fields {
+	fields := logger.Fields{
+		"hook":      string(hc.Event),
+		"direction": hc.Direction,
+	}
+	if hc.Migration != nil {
+		fields["migration"] = hc.Migration.Name
+	}
+	if hc.Batch > 0 {
+		fields["batch"] = hc.Batch
+	}
+	return fields
+}
@@ -0,0 +1,75 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vorzela/vorm/internal/tracing"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// lockPollInterval is how often a busy migration lock is retried
+const lockPollInterval = time.Second
+
+// lockName identifies the advisory lock shared by every vorm process
+// migrating the same migrations table
+func (e *Executor) lockName() string {
+	return "vorm:" + e.config.Migration.Table
+}
+
+// AcquireLock takes the session advisory lock that serializes migration runs
+// It waits up to migration.lock_timeout for another process to release it
+func (e *Executor) AcquireLock(ctx context.Context) (err error) {
+	ctx, span := e.tracer.Start(ctx, "acquire migration lock")
+	defer func() { tracing.End(span, err) }()
+
+	name := e.lockName()
+	timeout := e.config.Migration.LockTimeout
+	start := time.Now()
+	waited := false
+
+	for {
+		var acquired bool
+		err := e.conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, name).Scan(&acquired)
+		if err != nil {
+			return errors.NewMigrationError("Failed to acquire migration lock", err.Error(), "").WithCause(err)
+		}
+		if acquired {
+			if waited {
+				e.observers.lockWait(LockEvent{Lock: name, Waited: time.Since(start), Timeout: timeout, Acquired: true})
+			}
+			return nil
+		}
+
+		elapsed := time.Since(start)
+		if timeout > 0 && elapsed >= timeout {
+			return e.lockFailed(errors.NewLockTimeoutError(
+				"Timed out waiting for the migration lock",
+				fmt.Sprintf("another vorm process held %s for longer than %s (migration.lock_timeout)", name, timeout),
+			))
+		}
+		e.observers.lockWait(LockEvent{Lock: name, Waited: elapsed, Timeout: timeout})
+		waited = true
+
+		select {
+		case <-ctx.Done():
+			return e.lockFailed(errors.NewInterruptedError("Stopped waiting for the migration lock", ctx.Err().Error()).WithCause(ctx.Err()))
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// lockFailed reports a failed wait for the lock to observers and returns err
+func (e *Executor) lockFailed(err error) error {
+	e.observers.error(ErrorEvent{Err: err})
+	return err
+}
+
+// ReleaseLock releases the migration lock taken by AcquireLock, even if
+// ctx was cancelled; closing the connection releases it as well
+func (e *Executor) ReleaseLock(ctx context.Context) {
+	if _, err := e.conn.Conn().Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock(hashtext($1))`, e.lockName()); err != nil {
+		e.logger.Warning("Migration", fmt.Sprintf("Failed to release migration lock: %v", err))
+	}
+}
@@ -3,10 +3,15 @@ package migration
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/vorzela/vorm/internal/config"
 	"github.com/vorzela/vorm/internal/database"
 	"github.com/vorzela/vorm/internal/logger"
+	"github.com/vorzela/vorm/internal/tracing"
+	"github.com/vorzela/vorm/internal/utils"
+	"github.com/vorzela/vorm/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Manager coordinates all migration operations
@@ -16,24 +21,62 @@ type Manager struct {
 	creator   *database.Creator
 	generator *Generator
 	executor  *Executor
-	logger    *logger.Logger
+	logger    logger.Logger
+	runID     string
+	fields    logger.Fields // Attached to every log event of this run
+	observers []Observer    // Added to the executor on Initialize
+	seeders   []*Seeder     // Go seeders, run after the SQL seeders
+	tracer    trace.Tracer
 }
 
 // NewManager creates a new migration manager
-func NewManager(cfg *config.Config, logger *logger.Logger) (*Manager, error) {
+func NewManager(cfg *config.Config, log logger.Logger) (*Manager, error) {
 	conn := database.NewConnection(cfg)
 	creator := database.NewCreator(cfg)
 	generator := NewGenerator(cfg)
 
+	// Every event of this run carries the run ID, which is also stored on
+	// the tracking rows so logs and database history can be joined
+	runID := utils.GenerateRunID()
+	fields := logger.Fields{
+		"run_id":      runID,
+		"environment": cfg.Environment,
+		"db":          cfg.Database.Database,
+	}
+
 	return &Manager{
 		config:    cfg,
 		conn:      conn,
 		creator:   creator,
 		generator: generator,
-		logger:    logger,
+		logger:    logger.WithFields(log, fields),
+		runID:     runID,
+		fields:    fields,
+		tracer:    tracing.Tracer(nil),
 	}, nil
 }
 
+// RunID returns the identifier of this run
+func (m *Manager) RunID() string {
+	return m.runID
+}
+
+// SetCommand records the command being run in every log event
+func (m *Manager) SetCommand(command string) {
+	m.fields["command"] = command
+}
+
+// SetSource replaces the source migrations are loaded from
+// Use it to run migrations embedded in the binary or shipped as an archive
+func (m *Manager) SetSource(source Source) {
+	m.generator.SetSource(source)
+}
+
+// AddObserver registers an observer for migration progress events
+func (m *Manager) AddObserver(observer Observer) {
+	m.observers = append(m.observers, observer)
+}
+
 // Initialize sets up the migration system
 func (m *Manager) Initialize(ctx context.Context) error {
 	// Ensure database exists
@@ -48,21 +91,39 @@ func (m *Manager) Initialize(ctx context.Context) error {
 
 	// Create executor after connection is established
 	m.executor = NewExecutor(m.config, m.conn, m.logger)
+	m.executor.SetRunID(m.runID)
+	m.executor.SetTracer(m.tracer)
+	for _, observer := range m.observers {
+		m.executor.AddObserver(observer)
+	}
 
-	// Create migrations table
-	if err := m.creator.CreateMigrationsTable(ctx, m.conn); err != nil {
+	if err := m.setupSchema(ctx); err != nil {
 		return err
 	}
 
-	m.logger.LogDatabaseConnection(m.config.Database.Database)
+	m.logger.Info("Database", fmt.Sprintf("Connected to database: %s", m.config.Database.Database))
 	return nil
 }
 
+// setupSchema creates or upgrades vorm's own tables, then makes sure they look right
+func (m *Manager) setupSchema(ctx context.Context) error {
+	if err := m.creator.UpgradeSchema(ctx, m.conn); err != nil {
+		return err
+	}
+	return database.NewValidator(m.config).ValidateSchemaStructure(ctx, m.conn)
+}
+
+// AddMigration registers a migration defined in code, run in timestamp
+// order with the migration files
+func (m *Manager) AddMigration(migration *Migration) {
+	m.generator.AddMigration(migration)
+}
+
 // CreateMigration creates a new migration file
-func (m *Manager) CreateMigration(name string) (*Migration, error) {
+func (m *Manager) CreateMigration(name string, fields ...Field) (*Migration, error) {
 	m.logger.Info("Migration", fmt.Sprintf("Creating migration: %s", name))
 
-	migration, err := m.generator.GenerateMigration(name)
+	migration, err := m.generator.GenerateMigration(name, fields...)
 	if err != nil {
 		return nil, err
 	}
@@ -72,12 +133,25 @@ func (m *Manager) CreateMigration(name string) (*Migration, error) {
 }
 
 // RunMigrations executes pending migrations
-func (m *Manager) RunMigrations(ctx context.Context, limit int) error {
+func (m *Manager) RunMigrations(ctx context.Context, limit int) (err error) {
+	ctx, span := m.startSpan(ctx, "migrate")
+	defer func() { tracing.End(span, err) }()
+
 	if err := m.Initialize(ctx); err != nil {
 		return err
 	}
 	defer m.conn.Close(ctx)
 
+	if err := m.executor.AcquireLock(ctx); err != nil {
+		return err
+	}
+	defer m.executor.ReleaseLock(ctx)
+
+	return m.runPending(ctx, limit)
+}
+
+// runPending executes pending migrations on an initialized, locked manager
+func (m *Manager) runPending(ctx context.Context, limit int) error {
 	// Load all migrations
 	allMigrations, err := m.generator.LoadMigrations()
 	if err != nil {
@@ -101,12 +175,20 @@ func (m *Manager) RunMigrations(ctx context.Context, limit int) error {
 }
 
 // RollbackMigrations rolls back migrations
-func (m *Manager) RollbackMigrations(ctx context.Context, limit int) error {
+func (m *Manager) RollbackMigrations(ctx context.Context, limit int) (err error) {
+	ctx, span := m.startSpan(ctx, "rollback")
+	defer func() { tracing.End(span, err) }()
+
 	if err := m.Initialize(ctx); err != nil {
 		return err
 	}
 	defer m.conn.Close(ctx)
 
+	if err := m.executor.AcquireLock(ctx); err != nil {
+		return err
+	}
+	defer m.executor.ReleaseLock(ctx)
+
 	// Load all migrations
 	allMigrations, err := m.generator.LoadMigrations()
 	if err != nil {
@@ -128,29 +210,48 @@ func (m *Manager) RollbackMigrations(ctx context.Context, limit int) error {
 }
 
 // RollbackSteps rolls back a specific number of migration steps
-func (m *Manager) RollbackSteps(ctx context.Context, steps int) error {
+func (m *Manager) RollbackSteps(ctx context.Context, steps int) (err error) {
+	ctx, span := m.startSpan(ctx, "rollback")
+	defer func() { tracing.End(span, err) }()
+
 	if err := m.Initialize(ctx); err != nil {
 		return err
 	}
 	defer m.conn.Close(ctx)
 
+	if err := m.executor.AcquireLock(ctx); err != nil {
+		return err
+	}
+	defer m.executor.ReleaseLock(ctx)
+
 	// Load all migrations
 	allMigrations, err := m.generator.LoadMigrations()
 	if err != nil {
 		return err
 	}
 
-	// Get executed migrations in reverse order
-	executedMigrations, err := m.executor.GetTracker().GetExecutedMigrations(ctx)
+	migrationsToRollback, err := m.stepsToRollback(ctx, allMigrations, steps)
 	if err != nil {
 		return err
 	}
 
-	if len(executedMigrations) == 0 {
+	if len(migrationsToRollback) == 0 {
 		m.logger.Info("Migration", "No migrations to rollback")
 		return nil
 	}
 
+	return m.executor.RollbackMigrations(ctx, migrationsToRollback, 0)
+}
+
+// stepsToRollback returns the last executed migrations, newest first
+// A steps value of 0 returns every executed migration
+func (m *Manager) stepsToRollback(ctx context.Context, allMigrations []*Migration, steps int) ([]*Migration, error) {
+	// Get executed migrations in reverse order
+	executedMigrations, err := m.executor.GetTracker().GetExecutedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Reverse order for rollback and limit steps
 	for i := len(executedMigrations)/2 - 1; i >= 0; i-- {
 		opp := len(executedMigrations) - 1 - i
@@ -161,29 +262,120 @@ func (m *Manager) RollbackSteps(ctx context.Context, steps int) error {
 		executedMigrations = executedMigrations[:steps]
 	}
 
-	// Get migration files for Down SQL
+	return matchMigrationFiles(executedMigrations, allMigrations)
+}
+
+// matchMigrationFiles maps tracked migrations to their loaded files for Down SQL
+// The batch recorded in the tracking table is copied to the loaded migration.
+// Tracked migrations without a definition, such as a deleted file or a Go
+// migration registered by another program, are an error: skipping them
+// would leave them applied while their batch looks rolled back
+func matchMigrationFiles(tracked, allMigrations []*Migration) ([]*Migration, error) {
 	migrationsMap := make(map[string]*Migration)
 	for _, migration := range allMigrations {
 		migrationsMap[migration.Name] = migration
 	}
 
-	var migrationsToRollback []*Migration
-	for _, executedMigration := range executedMigrations {
-		if fullMigration, exists := migrationsMap[executedMigration.Name]; exists {
-			migrationsToRollback = append(migrationsToRollback, fullMigration)
+	var matched []*Migration
+	var missing []string
+	for _, trackedMigration := range tracked {
+		fullMigration, exists := migrationsMap[trackedMigration.Name]
+		if !exists {
+			missing = append(missing, trackedMigration.Name)
+			continue
 		}
+		fullMigration.Batch = trackedMigration.Batch
+		matched = append(matched, fullMigration)
 	}
 
-	return m.executor.RollbackMigrations(ctx, migrationsToRollback, 0)
+	if len(missing) > 0 {
+		return nil, errors.NewValidationError("Applied migrations have no definition",
+			fmt.Sprintf("%s; restore their files, or roll back from the program that registers them if they are Go or schema builder migrations",
+				strings.Join(missing, ", ")))
+	}
+	return matched, nil
+}
+
+// PlanMigrations returns the migrations RunMigrations would execute and their batch
+func (m *Manager) PlanMigrations(ctx context.Context, limit int) (_ []*Migration, _ int, err error) {
+	ctx, span := m.startSpan(ctx, "plan migrate")
+	defer func() { tracing.End(span, err) }()
+
+	if err := m.Initialize(ctx); err != nil {
+		return nil, 0, err
+	}
+	defer m.conn.Close(ctx)
+
+	allMigrations, err := m.generator.LoadMigrations()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pendingMigrations, err := m.executor.GetTracker().GetPendingMigrations(ctx, allMigrations)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if limit > 0 && limit < len(pendingMigrations) {
+		pendingMigrations = pendingMigrations[:limit]
+	}
+
+	lastBatch, err := m.executor.GetTracker().GetLastBatch(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return pendingMigrations, lastBatch + 1, nil
+}
+
+// PlanRollback returns the migrations a rollback would revert, newest first
+// A steps value of 0 plans a rollback of the last batch
+func (m *Manager) PlanRollback(ctx context.Context, steps int) (_ []*Migration, err error) {
+	ctx, span := m.startSpan(ctx, "plan rollback")
+	defer func() { tracing.End(span, err) }()
+
+	if err := m.Initialize(ctx); err != nil {
+		return nil, err
+	}
+	defer m.conn.Close(ctx)
+
+	allMigrations, err := m.generator.LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if steps > 0 {
+		return m.stepsToRollback(ctx, allMigrations, steps)
+	}
+
+	lastBatch, err := m.executor.GetTracker().GetLastBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batchMigrations, err := m.executor.GetTracker().GetMigrationsByBatch(ctx, lastBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	return matchMigrationFiles(batchMigrations, allMigrations)
 }
 
 // ResetAllMigrations rolls back all migrations
-func (m *Manager) ResetAllMigrations(ctx context.Context) error {
+func (m *Manager) ResetAllMigrations(ctx context.Context) (err error) {
+	ctx, span := m.startSpan(ctx, "reset")
+	defer func() { tracing.End(span, err) }()
+
 	if err := m.Initialize(ctx); err != nil {
 		return err
 	}
 	defer m.conn.Close(ctx)
 
+	if err := m.executor.AcquireLock(ctx); err != nil {
+		return err
+	}
+	defer m.executor.ReleaseLock(ctx)
+
 	allMigrations, err := m.generator.LoadMigrations()
 	if err != nil {
 		return err
@@ -193,28 +385,37 @@ func (m *Manager) ResetAllMigrations(ctx context.Context) error {
 }
 
 // FreshMigrations drops all tables and re-runs migrations
-func (m *Manager) FreshMigrations(ctx context.Context) error {
+func (m *Manager) FreshMigrations(ctx context.Context) (err error) {
+	ctx, span := m.startSpan(ctx, "fresh")
+	defer func() { tracing.End(span, err) }()
+
 	if err := m.Initialize(ctx); err != nil {
 		return err
 	}
 	defer m.conn.Close(ctx)
 
+	if err := m.executor.AcquireLock(ctx); err != nil {
+		return err
+	}
+	defer m.executor.ReleaseLock(ctx)
+
 	// Drop all tables
 	if err := m.creator.DropAllTables(ctx, m.conn); err != nil {
 		return err
 	}
 
-	// Recreate migrations table
-	if err := m.creator.CreateMigrationsTable(ctx, m.conn); err != nil {
+	// Recreate vorm's tables, then run all migrations
+	if err := m.setupSchema(ctx); err != nil {
 		return err
 	}
-
-	// Run all migrations
-	return m.RunMigrations(ctx, 0)
+	return m.runPending(ctx, 0)
 }
 
 // GetMigrationStatus returns the status of all migrations
-func (m *Manager) GetMigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+func (m *Manager) GetMigrationStatus(ctx context.Context) (_ []MigrationStatus, err error) {
+	ctx, span := m.startSpan(ctx, "status")
+	defer func() { tracing.End(span, err) }()
+
 	if err := m.Initialize(ctx); err != nil {
 		return nil, err
 	}
@@ -234,7 +435,10 @@ func (m *Manager) ListMigrations() ([]*Migration, error) {
 }
 
 // GetMigrationHistory returns migration execution history
-func (m *Manager) GetMigrationHistory(ctx context.Context) ([]*Migration, error) {
+func (m *Manager) GetMigrationHistory(ctx context.Context) (_ []*Migration, err error) {
+	ctx, span := m.startSpan(ctx, "history")
+	defer func() { tracing.End(span, err) }()
+
 	if err := m.Initialize(ctx); err != nil {
 		return nil, err
 	}
@@ -243,6 +447,19 @@ func (m *Manager) GetMigrationHistory(ctx context.Context) ([]*Migration, error)
 	return m.executor.GetTracker().GetMigrationHistory(ctx)
 }
 
+// GetAuditEntries returns audit table entries matching filter, newest first
+func (m *Manager) GetAuditEntries(ctx context.Context, filter AuditFilter) (_ []*AuditEntry, err error) {
+	ctx, span := m.startSpan(ctx, "audit")
+	defer func() { tracing.End(span, err) }()
+
+	if err := m.Initialize(ctx); err != nil {
+		return nil, err
+	}
+	defer m.conn.Close(ctx)
+
+	return m.executor.GetTracker().GetAuditEntries(ctx, filter)
+}
+
 // Close closes the database connection
 func (m *Manager) Close(ctx context.Context) {
 	if m.conn != nil {
@@ -0,0 +1,205 @@
+package migration
+
+import (
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/vorzela/vorm/internal/logger"
+	"github.com/vorzela/vorm/internal/utils"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// Observer receives progress events from migration runs
+// Callbacks run synchronously on the migrating goroutine and should return quickly
+type Observer interface {
+	OnPlan(event PlanEvent)
+	OnMigrationStart(event MigrationEvent)
+	OnStatement(event StatementEvent)
+	OnMigrationDone(event MigrationEvent)
+	OnRollback(event MigrationEvent)
+	OnError(event ErrorEvent)
+	OnLockWait(event LockEvent)
+}
+
+// PlanEvent lists the migrations a run is about to apply or roll back
+// Migrations is empty when there is nothing to do
+type PlanEvent struct {
+	Direction  string
+	Batch      int // Batch new migrations are recorded in, 0 for rollbacks
+	Pending    int // Pending migrations before the run, including any beyond the step limit (up only)
+	Migrations []*Migration
+}
+
+// MigrationEvent describes a migration being applied or rolled back
+type MigrationEvent struct {
+	Migration *Migration
+	Direction string
+	Batch     int
+	Duration  time.Duration // Set once the migration is committed
+}
+
+// StatementEvent describes a statement executed by a migration
+type StatementEvent struct {
+	Migration *Migration
+	Direction string
+	Index     int // 1-based position within the section
+	Total     int
+	Statement Statement
+	Duration  time.Duration
+}
+
+// ErrorEvent describes a failed run
+type ErrorEvent struct {
+	Migration *Migration // nil if the run failed outside a migration, e.g. in a hook
+	Direction string
+	Batch     int
+	Err       error
+}
+
+// LockEvent reports a wait for the migration lock held by another process
+// The last event of a wait that ended with the lock taken has Acquired set
+type LockEvent struct {
+	Lock     string
+	Waited   time.Duration
+	Timeout  time.Duration // 0 waits indefinitely
+	Acquired bool
+}
+
+// observers dispatches each event to every observer in order
+type observers []Observer
+
+func (o observers) plan(event PlanEvent) {
+	for _, observer := range o {
+		observer.OnPlan(event)
+	}
+}
+
+func (o observers) migrationStart(event MigrationEvent) {
+	for _, observer := range o {
+		observer.OnMigrationStart(event)
+	}
+}
+
+func (o observers) statement(event StatementEvent) {
+	for _, observer := range o {
+		observer.OnStatement(event)
+	}
+}
+
+func (o observers) migrationDone(event MigrationEvent) {
+	for _, observer := range o {
+		observer.OnMigrationDone(event)
+	}
+}
+
+func (o observers) rollback(event MigrationEvent) {
+	for _, observer := range o {
+		observer.OnRollback(event)
+	}
+}
+
+func (o observers) error(event ErrorEvent) {
+	for _, observer := range o {
+		observer.OnError(event)
+	}
+}
+
+func (o observers) lockWait(event LockEvent) {
+	for _, observer := range o {
+		observer.OnLockWait(event)
+	}
+}
+
+// logObserver writes migration progress to a logger
+// Every executor starts with one, so the log and console see each run
+type logObserver struct {
+	logger logger.Logger
+}
+
+// newLogObserver creates an observer logging to log
+func newLogObserver(log logger.Logger) *logObserver {
+	return &logObserver{logger: log}
+}
+
+func (o *logObserver) OnPlan(event PlanEvent) {
+	if len(event.Migrations) == 0 {
+		return
+	}
+	if event.Direction == DirectionDown {
+		o.logger.Warning("Migration", fmt.Sprintf("Rolling back %d migrations", len(event.Migrations)))
+		return
+	}
+	o.logger.Info("Migration", fmt.Sprintf("Running %d migrations in batch %d", len(event.Migrations), event.Batch))
+}
+
+func (o *logObserver) OnMigrationStart(event MigrationEvent) {
+	fields := logger.Fields{
+		"migration": event.Migration.Name,
+		"batch":     event.Batch,
+	}
+	if event.Direction == DirectionDown {
+		logger.Event(o.logger, logger.WARNING, "Migration", fmt.Sprintf("Rolling back: %s", event.Migration.Name), fields)
+		return
+	}
+	logger.Event(o.logger, logger.INFO, "Migration", fmt.Sprintf("Starting migration: %s", event.Migration.Name), fields)
+}
+
+func (o *logObserver) OnStatement(event StatementEvent) {
+	o.logger.Debug("Migration", fmt.Sprintf("Executed statement %d/%d of %s (line %d, %s)",
+		event.Index, event.Total, event.Migration.Name, event.Statement.Line, utils.FormatDuration(event.Duration)))
+}
+
+func (o *logObserver) OnMigrationDone(event MigrationEvent) {
+	logger.Event(o.logger, logger.SUCCESS, "Migration", fmt.Sprintf("Completed: %s (%s)", event.Migration.Name, utils.FormatDuration(event.Duration)), logger.Fields{
+		"migration":   event.Migration.Name,
+		"batch":       event.Batch,
+		"duration_ms": event.Duration.Milliseconds(),
+	})
+}
+
+func (o *logObserver) OnRollback(event MigrationEvent) {
+	logger.Event(o.logger, logger.SUCCESS, "Migration", fmt.Sprintf("Rolled back: %s", event.Migration.Name), logger.Fields{
+		"migration":   event.Migration.Name,
+		"batch":       event.Batch,
+		"duration_ms": event.Duration.Milliseconds(),
+	})
+}
+
+// OnError logs the failure with the statement and SQLSTATE if known
+func (o *logObserver) OnError(event ErrorEvent) {
+	fields := logger.Fields{}
+	message := fmt.Sprintf("Failed: %v", event.Err)
+	if event.Migration != nil {
+		fields["migration"] = event.Migration.Name
+		message = fmt.Sprintf("Failed: %s - %v", event.Migration.Name, event.Err)
+	}
+	if event.Batch > 0 {
+		fields["batch"] = event.Batch
+	}
+
+	var migrationErr *errors.MigrationError
+	if stderrors.As(event.Err, &migrationErr) {
+		if migrationErr.Statement > 0 {
+			fields["statement_index"] = migrationErr.Statement
+		}
+		if migrationErr.SQLState != "" {
+			fields["sqlstate"] = migrationErr.SQLState
+		}
+	}
+
+	logger.Event(o.logger, logger.ERROR, "Migration", message, fields)
+}
+
+// OnLockWait warns once when the wait starts, then logs at debug level
+func (o *logObserver) OnLockWait(event LockEvent) {
+	if event.Acquired {
+		o.logger.Info("Migration", fmt.Sprintf("Acquired the migration lock after %s", utils.FormatDuration(event.Waited)))
+		return
+	}
+	if event.Waited < lockPollInterval {
+		o.logger.Warning("Migration", "Waiting for the migration lock held by another vorm process")
+		return
+	}
+	o.logger.Debug("Migration", fmt.Sprintf("Still waiting for the migration lock (%s)", utils.FormatDuration(event.Waited)))
+}
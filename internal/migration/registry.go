@@ -0,0 +1,140 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vorzela/vorm/internal/utils"
+	"github.com/vorzela/vorm/pkg/errors"
+	"github.com/vorzela/vorm/pkg/vorm/schema"
+)
+
+// MigrationFunc is the Up or Down step of a Go migration
+// Returning an error rolls back tx
+type MigrationFunc func(ctx context.Context, tx pgx.Tx) error
+
+// NewSchemaMigration creates a migration written with the schema builder
+// version is named like a migration file without extension, such as
+// 2025_07_01_120000_create_users, and orders it among the files. The
+// checksum covers the operations rather than the SQL compiled from them,
+// so changes to the compiler don't make applied migrations drift
+func NewSchemaMigration(version string, ops ...schema.Operation) (*Migration, error) {
+	name, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	upSQL, downSQL, err := schema.Compile(ops...)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256([]byte(version + "\n" + schema.Fingerprint(ops...)))
+	return &Migration{
+		Name:     name,
+		Filename: version,
+		Checksum: fmt.Sprintf("%x", checksum),
+		UpSQL:    upSQL,
+		DownSQL:  downSQL,
+		UpLine:   1,
+		DownLine: 1,
+	}, nil
+}
+
+// NewGoMigration creates a migration running Go functions
+// Its code can't be hashed, so the checksum covers the version it was
+// registered under. A nil down makes it irreversible
+func NewGoMigration(version string, up, down MigrationFunc) (*Migration, error) {
+	name, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	if up == nil {
+		return nil, errors.NewValidationError("Invalid Go migration", fmt.Sprintf("%s: the up function is required", version))
+	}
+
+	checksum := sha256.Sum256([]byte(version))
+	return &Migration{
+		Name:     name,
+		Filename: version,
+		Checksum: fmt.Sprintf("%x", checksum),
+		Up:       up,
+		Down:     down,
+	}, nil
+}
+
+// goMigration is a Go migration registered with RegisterGoMigration
+type goMigration struct {
+	version  string
+	up, down MigrationFunc
+}
+
+var (
+	goMigrationsMu sync.Mutex
+	goMigrations   []goMigration
+)
+
+// RegisterGoMigration registers a Go migration process-wide, for every
+// client and test database created afterwards
+func RegisterGoMigration(version string, up, down MigrationFunc) {
+	goMigrationsMu.Lock()
+	defer goMigrationsMu.Unlock()
+	goMigrations = append(goMigrations, goMigration{version: version, up: up, down: down})
+}
+
+// GoMigrations returns new migrations for the registered Go migrations,
+// in the order they were registered
+func GoMigrations() ([]*Migration, error) {
+	goMigrationsMu.Lock()
+	defer goMigrationsMu.Unlock()
+
+	migrations := make([]*Migration, 0, len(goMigrations))
+	for _, registered := range goMigrations {
+		m, err := NewGoMigration(registered.version, registered.up, registered.down)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, m)
+	}
+	return migrations, nil
+}
+
+// parseVersion returns the migration name of a version
+func parseVersion(version string) (string, error) {
+	_, name, valid := utils.ParseMigrationFilename(version)
+	if !valid || strings.HasSuffix(version, ".sql") {
+		return "", errors.NewValidationError("Invalid migration version",
+			fmt.Sprintf("%s: use YYYY_MM_DD_HHMMSS_name", version))
+	}
+	return name, nil
+}
+
+// AddMigration registers a migration defined in code, loaded with the
+// migration files and ordered with them by timestamp
+func (g *Generator) AddMigration(migration *Migration) {
+	g.registered = append(g.registered, migration)
+}
+
+// mergeRegistered adds the registered migrations to the loaded files
+// A migration name may only be used once
+func (g *Generator) mergeRegistered(migrations []*Migration) ([]*Migration, error) {
+	names := make(map[string]string, len(migrations))
+	for _, migration := range migrations {
+		names[migration.Name] = migration.Filename
+	}
+
+	for _, migration := range g.registered {
+		if existing, found := names[migration.Name]; found {
+			return nil, errors.NewValidationError("Duplicate migration",
+				fmt.Sprintf("%s is both %s and %s", migration.Name, existing, migration.Filename))
+		}
+		names[migration.Name] = migration.Filename
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
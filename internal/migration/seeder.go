@@ -0,0 +1,369 @@
+package migration
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vorzela/vorm/internal/database"
+	"github.com/vorzela/vorm/internal/tracing"
+	"github.com/vorzela/vorm/internal/utils"
+	"github.com/vorzela/vorm/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// seedDirective starts the comment lines that configure a SQL seeder
+const seedDirective = "-- +seed"
+
+// SeedFunc seeds the database within the seeder's transaction
+type SeedFunc func(ctx context.Context, tx pgx.Tx) error
+
+// Seeder fills the database with data, from a SQL file in the seeders
+// directory or a Go function registered through pkg/vorm
+type Seeder struct {
+	Name         string   `json:"name" yaml:"name"`
+	Filename     string   `json:"filename,omitempty" yaml:"filename,omitempty"`
+	Filepath     string   `json:"filepath,omitempty" yaml:"filepath,omitempty"`
+	Checksum     string   `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	Environments []string `json:"environments,omitempty" yaml:"environments,omitempty"` // Empty runs in every environment
+	Once         bool     `json:"once" yaml:"once"`                                     // Recorded, and skipped on later runs
+	SQL          string   `json:"-" yaml:"-"`
+	Func         SeedFunc `json:"-" yaml:"-"` // Go seeders only
+}
+
+// EnabledIn returns true if the seeder runs in environment
+func (s *Seeder) EnabledIn(environment string) bool {
+	if len(s.Environments) == 0 {
+		return true
+	}
+	for _, env := range s.Environments {
+		if strings.EqualFold(env, environment) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSeederSource replaces the source SQL seeders are loaded from
+func (g *Generator) SetSeederSource(source Source) {
+	g.seeders = source
+}
+
+// GenerateSeeder creates a new SQL seeder file
+func (g *Generator) GenerateSeeder(name string) (*Seeder, error) {
+	// Seeders are named like migrations, so they run in creation order and
+	// are selected by the snake_case name in their filename
+	name = utils.SanitizeMigrationName(name)
+	filename := utils.GenerateMigrationFilename(name)
+	path := filepath.Join(g.config.GetSeedersPath(), filename)
+
+	content := fmt.Sprintf(`-- Seeder: %s
+-- Created: %s
+--
+-- Limit the seeder to some environments, or run it only once per database,
+-- with directives such as:
+--   -- +seed environments: development, staging
+--   -- +seed once
+
+-- INSERT INTO users (name, email) VALUES ('Demo User', 'demo@example.com');
+`, name, time.Now().Format("2006-01-02 15:04:05"))
+
+	if err := utils.CreateFile(path, content); err != nil {
+		return nil, errors.NewFileError("Failed to create seeder file", err.Error()).WithCause(err)
+	}
+
+	return &Seeder{
+		Name:     name,
+		Filename: filename,
+		Filepath: path,
+		Checksum: g.calculateChecksum(content),
+	}, nil
+}
+
+// LoadSeeders loads all SQL seeders, in filename order
+// A missing seeders directory means there are none
+func (g *Generator) LoadSeeders() ([]*Seeder, error) {
+	if dir, ok := g.seeders.(*DirSource); ok {
+		if _, err := os.Stat(dir.dir); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+
+	files, err := g.seeders.ReadFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var seeders []*Seeder
+	for _, file := range files {
+		seeder, err := g.loadSeederFile(file)
+		if err != nil {
+			return nil, err
+		}
+		seeders = append(seeders, seeder)
+	}
+	return seeders, nil
+}
+
+// loadSeederFile parses a single SQL seeder and its directives
+func (g *Generator) loadSeederFile(file SourceFile) (*Seeder, error) {
+	_, name, valid := utils.ParseMigrationFilename(file.Filename)
+	if !valid {
+		return nil, errors.NewValidationError("Invalid seeder filename", file.Filename)
+	}
+
+	seeder := &Seeder{
+		Name:     name,
+		Filename: file.Filename,
+		Filepath: file.Path,
+		Checksum: g.calculateChecksum(file.Content),
+		SQL:      file.Content,
+	}
+
+	for i, line := range strings.Split(file.Content, "\n") {
+		directive, ok := strings.CutPrefix(strings.TrimSpace(line), seedDirective)
+		if !ok {
+			continue
+		}
+
+		key, value, _ := strings.Cut(strings.TrimSpace(directive), ":")
+		switch strings.TrimSpace(key) {
+		case "once":
+			seeder.Once = true
+		case "environments":
+			for _, env := range strings.Split(value, ",") {
+				if env = strings.TrimSpace(env); env != "" {
+					seeder.Environments = append(seeder.Environments, env)
+				}
+			}
+		default:
+			return nil, errors.NewValidationError("Unknown seeder directive",
+				fmt.Sprintf("%s:%d: %q; use '-- +seed once' or '-- +seed environments: a, b'", file.Path, i+1, strings.TrimSpace(line)))
+		}
+	}
+
+	return seeder, nil
+}
+
+// SetSeederSource replaces the source SQL seeders are loaded from
+func (m *Manager) SetSeederSource(source Source) {
+	m.generator.SetSeederSource(source)
+}
+
+// AddSeeder registers a Go seeder, run after the SQL seeders in the order added
+func (m *Manager) AddSeeder(seeder *Seeder) {
+	m.seeders = append(m.seeders, seeder)
+}
+
+// ListSeeders returns the SQL seeders followed by the registered Go seeders
+func (m *Manager) ListSeeders() ([]*Seeder, error) {
+	seeders, err := m.generator.LoadSeeders()
+	if err != nil {
+		return nil, err
+	}
+	seeders = append(seeders, m.seeders...)
+
+	seen := make(map[string]bool, len(seeders))
+	for _, seeder := range seeders {
+		if seeder.Name == "" {
+			return nil, errors.NewValidationError("Seeder name is required", "every Go seeder needs a name")
+		}
+		if seen[seeder.Name] {
+			return nil, errors.NewValidationError("Duplicate seeder name", seeder.Name)
+		}
+		seen[seeder.Name] = true
+	}
+	return seeders, nil
+}
+
+// RunSeeders runs the seeders enabled in the current environment
+// With names, only those seeders run, in the usual order
+func (m *Manager) RunSeeders(ctx context.Context, names []string) (err error) {
+	ctx, span := m.startSpan(ctx, "seed")
+	defer func() { tracing.End(span, err) }()
+
+	seeders, err := m.ListSeeders()
+	if err != nil {
+		return err
+	}
+	seeders, err = m.selectSeeders(seeders, names)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Initialize(ctx); err != nil {
+		return err
+	}
+	defer m.conn.Close(ctx)
+
+	if err := m.executor.AcquireLock(ctx); err != nil {
+		return err
+	}
+	defer m.executor.ReleaseLock(ctx)
+
+	return m.executor.RunSeeders(ctx, seeders)
+}
+
+// selectSeeders picks the seeders to run from all seeders
+// Asking for a seeder scoped to other environments is an error
+func (m *Manager) selectSeeders(seeders []*Seeder, names []string) ([]*Seeder, error) {
+	environment := m.config.Environment
+
+	if len(names) == 0 {
+		var selected []*Seeder
+		for _, seeder := range seeders {
+			if seeder.EnabledIn(environment) {
+				selected = append(selected, seeder)
+			} else {
+				m.logger.Debug("Seeder", fmt.Sprintf("Skipping %s: not enabled in %s", seeder.Name, environment))
+			}
+		}
+		return selected, nil
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		found := false
+		for _, seeder := range seeders {
+			if seeder.Name != name && strings.TrimSuffix(seeder.Filename, ".sql") != name {
+				continue
+			}
+			if !seeder.EnabledIn(environment) {
+				return nil, errors.NewValidationError(
+					fmt.Sprintf("Seeder %s doesn't run in %s", seeder.Name, environment),
+					fmt.Sprintf("it is limited to: %s", strings.Join(seeder.Environments, ", ")))
+			}
+			wanted[seeder.Name], found = true, true
+		}
+		if !found {
+			return nil, errors.NewValidationError("Seeder not found", name)
+		}
+	}
+
+	var selected []*Seeder
+	for _, seeder := range seeders {
+		if wanted[seeder.Name] {
+			selected = append(selected, seeder)
+		}
+	}
+	return selected, nil
+}
+
+// RunSeeders runs seeders in order, each in its own transaction
+// Seeders marked once are skipped if they already ran
+func (e *Executor) RunSeeders(ctx context.Context, seeders []*Seeder) error {
+	if len(seeders) == 0 {
+		e.logger.Info("Seeder", "No seeders to run")
+		return nil
+	}
+
+	ran := 0
+	for i, seeder := range seeders {
+		if err := ctx.Err(); err != nil {
+			return seedingInterrupted(ctx, err, seeders, i)
+		}
+
+		if seeder.Once {
+			executed, err := e.tracker.SeederExecuted(ctx, seeder.Name)
+			if err != nil {
+				return seedingInterrupted(ctx, err, seeders, i)
+			}
+			if executed {
+				e.logger.Info("Seeder", fmt.Sprintf("Skipping %s: already run", seeder.Name))
+				continue
+			}
+		}
+
+		if err := e.runSeeder(ctx, seeder); err != nil {
+			return seedingInterrupted(ctx, err, seeders, i)
+		}
+		ran++
+	}
+
+	e.logger.Success("Seeder", fmt.Sprintf("Successfully ran %d seeders", ran))
+	return nil
+}
+
+// runSeeder runs a single seeder and records it if it runs only once
+func (e *Executor) runSeeder(ctx context.Context, seeder *Seeder) (err error) {
+	ctx, span := e.tracer.Start(ctx, "seed "+seeder.Name, trace.WithAttributes(tracing.Seeder.String(seeder.Name)))
+	defer func() { tracing.End(span, err) }()
+
+	e.logger.Info("Seeder", fmt.Sprintf("Seeding: %s", seeder.Name))
+	start := time.Now()
+
+	tx, err := e.conn.Begin(ctx)
+	if err != nil {
+		return errors.NewMigrationError("Failed to begin transaction", err.Error(), "").WithCause(err)
+	}
+	// Roll back even if ctx was cancelled, so the connection stays usable
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	if seeder.Func != nil {
+		if err := seeder.Func(ctx, tx); err != nil {
+			return errors.NewMigrationError(fmt.Sprintf("Seeder %s failed", seeder.Name), err.Error(), "").WithCause(err)
+		}
+	} else if err := e.executeSeederSQL(ctx, tx, seeder); err != nil {
+		return err
+	}
+
+	duration := time.Since(start)
+	if seeder.Once {
+		if err := e.tracker.RecordSeeder(ctx, seeder, duration); err != nil {
+			return err
+		}
+	}
+
+	// Commit unless the run was cancelled meanwhile
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.NewMigrationError(fmt.Sprintf("Failed to commit seeder %s", seeder.Name), err.Error(), "").WithCause(err)
+	}
+
+	e.logger.Success("Seeder", fmt.Sprintf("Seeded: %s (%s)", seeder.Name, utils.FormatDuration(duration)))
+	return nil
+}
+
+// executeSeederSQL executes the statements of a SQL seeder within tx
+func (e *Executor) executeSeederSQL(ctx context.Context, tx pgx.Tx, seeder *Seeder) error {
+	for i, statement := range SplitStatements(seeder.SQL, 1) {
+		if _, err := database.ExecCancellable(ctx, tx.Conn(), statement.SQL); err != nil {
+			seedErr := errors.NewMigrationError(
+				fmt.Sprintf("Seeder %s failed at SQL statement %d", seeder.Name, i+1),
+				err.Error(),
+				"",
+			).WithCause(err)
+			seedErr.Statement = i + 1
+			seedErr.Location = statementLocation(seeder.Filepath, seeder.SQL, 1, statement, seedErr.PgError())
+			return seedErr
+		}
+	}
+	return nil
+}
+
+// seedingInterrupted turns err into an interrupted error if ctx was
+// cancelled, like interrupted does for migrations
+func seedingInterrupted(ctx context.Context, err error, seeders []*Seeder, completed int) error {
+	if ctx.Err() == nil || stderrors.Is(err, errors.ErrInterrupted) {
+		return err
+	}
+
+	reason := "cancelled"
+	if stderrors.Is(ctx.Err(), context.DeadlineExceeded) {
+		reason = "deadline exceeded"
+	}
+
+	var remaining []string
+	for _, seeder := range seeders[completed:] {
+		remaining = append(remaining, seeder.Name)
+	}
+	details := fmt.Sprintf("%s after %d of %d seeders; remaining: %s", reason, completed, len(seeders), strings.Join(remaining, ", "))
+	return errors.NewInterruptedError("Seeding interrupted", details).WithCause(ctx.Err())
+}
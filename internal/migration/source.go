@@ -0,0 +1,210 @@
+package migration
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/utils"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// SourceFile is a raw migration file read from a Source
+type SourceFile struct {
+	Filename string // Base name, e.g. 2025_06_14_180302_create_users_table.sql
+	Path     string // Location of the file within its source
+	Content  string
+}
+
+// Source provides the raw migration files for a Generator
+// Implementations exist for OS directories, any fs.FS (including go:embed)
+// and tar.gz archives, so migrations don't have to ship next to the binary
+type Source interface {
+	// ReadFiles returns all .sql migration files, sorted by filename
+	ReadFiles() ([]SourceFile, error)
+
+	// String describes the source for logs and error messages
+	String() string
+}
+
+// NewSourceFromConfig returns the source configured by migration.directory
+// A directory ending in .tar.gz or .tgz is read as an archive
+func NewSourceFromConfig(cfg *config.Config) Source {
+	if cfg.IsMigrationsArchive() {
+		return NewArchiveSource(cfg.GetMigrationsPath())
+	}
+	return NewDirSource(cfg.GetMigrationsPath())
+}
+
+// DirSource reads migrations from a directory on the local filesystem
+type DirSource struct {
+	dir string
+}
+
+// NewDirSource creates a source for an OS directory
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{dir: dir}
+}
+
+// ReadFiles reads all migration files from the directory
+func (s *DirSource) ReadFiles() ([]SourceFile, error) {
+	// Ensure migrations directory exists
+	if err := utils.EnsureDirectoryExists(s.dir); err != nil {
+		return nil, errors.NewFileError("Failed to access migrations directory", err.Error()).WithCause(err)
+	}
+
+	files, err := readFS(os.DirFS(s.dir), ".")
+	if err != nil {
+		return nil, errors.NewFileError("Failed to load migrations", err.Error()).WithCause(err)
+	}
+
+	// Report real paths so users can open the file
+	for i := range files {
+		files[i].Path = filepath.Join(s.dir, filepath.FromSlash(files[i].Path))
+	}
+
+	return files, nil
+}
+
+// String returns the directory path
+func (s *DirSource) String() string {
+	return s.dir
+}
+
+// FSSource reads migrations from an fs.FS, such as an embed.FS
+type FSSource struct {
+	fsys fs.FS
+	root string
+}
+
+// NewFSSource creates a source for the given root directory inside fsys
+// With //go:embed migrations/*.sql the root is "migrations"
+func NewFSSource(fsys fs.FS, root string) *FSSource {
+	if root == "" {
+		root = "."
+	}
+	return &FSSource{fsys: fsys, root: root}
+}
+
+// ReadFiles reads all migration files below the root directory
+func (s *FSSource) ReadFiles() ([]SourceFile, error) {
+	files, err := readFS(s.fsys, s.root)
+	if err != nil {
+		return nil, errors.NewFileError("Failed to load migrations", err.Error()).WithCause(err)
+	}
+	return files, nil
+}
+
+// String describes the embedded source
+func (s *FSSource) String() string {
+	return "fs:" + s.root
+}
+
+// ArchiveSource reads migrations from a tar.gz archive
+type ArchiveSource struct {
+	path string
+}
+
+// NewArchiveSource creates a source for a .tar.gz or .tgz archive
+func NewArchiveSource(path string) *ArchiveSource {
+	return &ArchiveSource{path: path}
+}
+
+// ReadFiles reads all migration files contained in the archive
+func (s *ArchiveSource) ReadFiles() ([]SourceFile, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, errors.NewFileError("Failed to open migrations archive", err.Error()).WithCause(err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, errors.NewFileError("Failed to read migrations archive", err.Error()).WithCause(err)
+	}
+	defer gz.Close()
+
+	var files []SourceFile
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.NewFileError("Failed to read migrations archive", err.Error()).WithCause(err)
+		}
+
+		// Skip directories and non-SQL files
+		if header.Typeflag != tar.TypeReg || !strings.HasSuffix(header.Name, ".sql") {
+			continue
+		}
+
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, errors.NewFileError("Failed to read migration from archive", err.Error()).WithCause(err)
+		}
+
+		files = append(files, SourceFile{
+			Filename: path.Base(header.Name),
+			Path:     s.path + ":" + header.Name,
+			Content:  string(content),
+		})
+	}
+
+	sortSourceFiles(files)
+	return files, nil
+}
+
+// String returns the archive path
+func (s *ArchiveSource) String() string {
+	return s.path
+}
+
+// readFS walks root inside fsys and reads every .sql file
+func readFS(fsys fs.FS, root string) ([]SourceFile, error) {
+	var files []SourceFile
+
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip directories and non-SQL files
+		if d.IsDir() || !strings.HasSuffix(p, ".sql") {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, SourceFile{
+			Filename: path.Base(p),
+			Path:     p,
+			Content:  string(content),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortSourceFiles(files)
+	return files, nil
+}
+
+// sortSourceFiles sorts files by filename (timestamp)
+func sortSourceFiles(files []SourceFile) {
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Filename < files[j].Filename
+	})
+}
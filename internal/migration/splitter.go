@@ -0,0 +1,173 @@
+package migration
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Statement is a single SQL statement from a migration section
+type Statement struct {
+	SQL    string // Statement text without the terminating semicolon
+	Line   int    // 1-based line of the first token in the migration file
+	Column int    // 1-based column (in characters) of the first token
+}
+
+// SplitStatements splits sql on top-level semicolons
+// Semicolons inside quoted strings, quoted identifiers, dollar-quoted bodies
+// and comments are ignored. startLine is the file line sql begins on, so the
+// reported positions point into the migration file. Statements made up only
+// of comments are dropped
+func SplitStatements(sql string, startLine int) []Statement {
+	var statements []Statement
+
+	line, column := startLine, 1
+	start := -1 // Byte offset of the current statement's first token
+	var current Statement
+
+	// advance moves past n bytes, keeping line and column up to date
+	advance := func(i, n int) int {
+		for _, r := range sql[i : i+n] {
+			if r == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+		return i + n
+	}
+
+	// begin marks the start of a statement at the first real token
+	begin := func(i int) {
+		if start < 0 {
+			start = i
+			current = Statement{Line: line, Column: column}
+		}
+	}
+
+	// finish closes the current statement, ending at byte offset end
+	finish := func(end int) {
+		if start >= 0 {
+			current.SQL = strings.TrimRightFunc(sql[start:end], unicode.IsSpace)
+			statements = append(statements, current)
+		}
+		start = -1
+	}
+
+	i := 0
+	for i < len(sql) {
+		c := sql[i]
+		switch {
+		case c == ';':
+			finish(i)
+			i = advance(i, 1)
+
+		case strings.HasPrefix(sql[i:], "--"):
+			// Line comment runs to the end of the line
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			i = advance(i, end)
+
+		case strings.HasPrefix(sql[i:], "/*"):
+			i = advance(i, blockCommentLength(sql[i:]))
+
+		case c == '\'' || c == '"':
+			begin(i)
+			// E'...' strings allow backslash escapes
+			escapes := c == '\'' && i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e') &&
+				(i == 1 || !isIdentByte(sql[i-2]))
+			i = advance(i, quotedLength(sql[i:], c, escapes))
+
+		case c == '$' && (i == 0 || !isIdentByte(sql[i-1])):
+			begin(i)
+			if tag, ok := dollarTag(sql[i:]); ok {
+				end := strings.Index(sql[i+len(tag):], tag)
+				if end < 0 {
+					end = len(sql) - i - len(tag)
+				} else {
+					end += len(tag)
+				}
+				i = advance(i, len(tag)+end)
+			} else {
+				i = advance(i, 1)
+			}
+
+		default:
+			r, size := utf8.DecodeRuneInString(sql[i:])
+			if !unicode.IsSpace(r) {
+				begin(i)
+			}
+			i = advance(i, size)
+		}
+	}
+	finish(len(sql))
+
+	return statements
+}
+
+// quotedLength returns the length of the quoted string or identifier at the
+// start of s, including both quotes; doubled quotes are escapes
+func quotedLength(s string, quote byte, backslashEscapes bool) int {
+	for i := 1; i < len(s); i++ {
+		if backslashEscapes && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] != quote {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(s)
+}
+
+// blockCommentLength returns the length of the block comment at the start
+// of s; PostgreSQL allows block comments to nest
+func blockCommentLength(s string) int {
+	depth := 0
+	for i := 0; i < len(s)-1; i++ {
+		switch {
+		case s[i] == '/' && s[i+1] == '*':
+			depth++
+			i++
+		case s[i] == '*' && s[i+1] == '/':
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+// dollarTag returns the opening tag ($$ or $name$) at the start of s
+// A $ followed by digits is a positional parameter, not a tag
+func dollarTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1], true
+		case c >= '0' && c <= '9' && i == 1:
+			return "", false
+		case isIdentByte(c):
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// isIdentByte reports whether c can be part of an unquoted identifier
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,63 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/vorzela/vorm/internal/tracing"
+)
+
+// SetTracerProvider sets the provider spans are created with
+// Without one, the global OpenTelemetry provider is used
+func (m *Manager) SetTracerProvider(provider trace.TracerProvider) {
+	m.tracer = tracing.Tracer(provider)
+}
+
+// startSpan starts the span of a manager operation such as migrate or status
+// Unless ctx already carries a span, it continues the trace given by the
+// TRACEPARENT environment variable
+func (m *Manager) startSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	command, _ := m.fields["command"].(string)
+	if command == "" {
+		command = operation
+	}
+
+	return m.tracer.Start(tracing.ContextFromEnv(ctx), "vorm "+operation, trace.WithAttributes(
+		tracing.Command.String(command),
+		tracing.RunID.String(m.runID),
+		tracing.Environment.String(m.config.Environment),
+		tracing.DBSystem.String("postgresql"),
+		tracing.DBName.String(m.config.Database.Database),
+	))
+}
+
+// SetTracer sets the tracer migration and statement spans are created with
+func (e *Executor) SetTracer(tracer trace.Tracer) {
+	e.tracer = tracer
+}
+
+// startMigrationSpan starts the span of a migration being applied or rolled back
+func (e *Executor) startMigrationSpan(ctx context.Context, migration *Migration, direction string, batch int) (context.Context, trace.Span) {
+	name := "migrate " + migration.Name
+	if direction == DirectionDown {
+		name = "rollback " + migration.Name
+	}
+
+	return e.tracer.Start(ctx, name, trace.WithAttributes(
+		tracing.Migration.String(migration.Name),
+		tracing.Direction.String(direction),
+		tracing.Batch.Int(batch),
+	))
+}
+
+// startStatementSpan starts the span of a single migration statement
+func (e *Executor) startStatementSpan(ctx context.Context, migration *Migration, index int, statement Statement) (context.Context, trace.Span) {
+	return e.tracer.Start(ctx, fmt.Sprintf("statement %d", index), trace.WithAttributes(
+		tracing.Migration.String(migration.Name),
+		tracing.StatementIndex.Int(index),
+		tracing.StatementLine.Int(statement.Line),
+		tracing.DBSystem.String("postgresql"),
+	))
+}
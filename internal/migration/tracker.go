@@ -8,6 +8,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/vorzela/vorm/internal/config"
 	"github.com/vorzela/vorm/internal/database"
+	"github.com/vorzela/vorm/internal/logger"
 	"github.com/vorzela/vorm/pkg/errors"
 )
 
@@ -15,27 +16,35 @@ import (
 type Tracker struct {
 	config *config.Config
 	conn   *database.Connection
+	logger logger.Logger
+	runID  string // Stored on every row this tracker records
 }
 
 // NewTracker creates a new migration tracker
-func NewTracker(cfg *config.Config, conn *database.Connection) *Tracker {
+func NewTracker(cfg *config.Config, conn *database.Connection, logger logger.Logger) *Tracker {
 	return &Tracker{
 		config: cfg,
 		conn:   conn,
+		logger: logger,
 	}
 }
 
+// SetRunID sets the run identifier stored with recorded migrations
+func (t *Tracker) SetRunID(runID string) {
+	t.runID = runID
+}
+
 // GetExecutedMigrations returns all executed migrations from database
 func (t *Tracker) GetExecutedMigrations(ctx context.Context) ([]*Migration, error) {
 	sql := fmt.Sprintf(`
-		SELECT id, migration, batch, executed_at, execution_time, checksum
+		SELECT id, migration, batch, executed_at, execution_time, checksum, COALESCE(run_id, '')
 		FROM %s
 		ORDER BY id ASC
 	`, pgx.Identifier{t.config.Migration.Table}.Sanitize())
 
 	rows, err := t.conn.Query(ctx, sql)
 	if err != nil {
-		return nil, errors.NewMigrationError("Failed to get executed migrations", err.Error(), "")
+		return nil, errors.NewMigrationError("Failed to get executed migrations", err.Error(), "").WithCause(err)
 	}
 	defer rows.Close()
 
@@ -49,9 +58,10 @@ func (t *Tracker) GetExecutedMigrations(ctx context.Context) ([]*Migration, erro
 			&migration.ExecutedAt,
 			&migration.ExecutionTime,
 			&migration.Checksum,
+			&migration.RunID,
 		)
 		if err != nil {
-			return nil, errors.NewMigrationError("Failed to scan migration row", err.Error(), "")
+			return nil, errors.NewMigrationError("Failed to scan migration row", err.Error(), "").WithCause(err)
 		}
 		migrations = append(migrations, migration)
 	}
@@ -86,8 +96,8 @@ func (t *Tracker) GetPendingMigrations(ctx context.Context, allMigrations []*Mig
 // RecordMigration records a successful migration execution
 func (t *Tracker) RecordMigration(ctx context.Context, migration *Migration, batch int, executionTime time.Duration) error {
 	sql := fmt.Sprintf(`
-		INSERT INTO %s (migration, batch, executed_at, execution_time, checksum)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO %s (migration, batch, executed_at, execution_time, checksum, run_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
 	`, pgx.Identifier{t.config.Migration.Table}.Sanitize())
 
 	_, err := t.conn.Conn().Exec(ctx, sql,
@@ -96,12 +106,14 @@ func (t *Tracker) RecordMigration(ctx context.Context, migration *Migration, bat
 		time.Now(),
 		int(executionTime.Milliseconds()),
 		migration.Checksum,
+		t.runID,
 	)
 
 	if err != nil {
-		return errors.NewMigrationError("Failed to record migration", err.Error(), migration.Name)
+		return errors.NewMigrationError("Failed to record migration", err.Error(), migration.Name).WithCause(err)
 	}
 
+	t.logger.Debug("Tracker", fmt.Sprintf("Recorded %s in batch %d", migration.Name, batch))
 	return nil
 }
 
@@ -113,9 +125,45 @@ func (t *Tracker) RemoveMigration(ctx context.Context, migration *Migration) err
 
 	_, err := t.conn.Conn().Exec(ctx, sql, migration.Name)
 	if err != nil {
-		return errors.NewMigrationError("Failed to remove migration record", err.Error(), migration.Name)
+		return errors.NewMigrationError("Failed to remove migration record", err.Error(), migration.Name).WithCause(err)
+	}
+
+	t.logger.Debug("Tracker", fmt.Sprintf("Removed record of %s", migration.Name))
+	return nil
+}
+
+// SeederExecuted returns true if a run-once seeder has already run
+func (t *Tracker) SeederExecuted(ctx context.Context, name string) (bool, error) {
+	sql := fmt.Sprintf(`
+		SELECT EXISTS (SELECT 1 FROM %s WHERE seeder = $1)
+	`, pgx.Identifier{t.config.GetSeedersTable()}.Sanitize())
+
+	var executed bool
+	if err := t.conn.QueryRow(ctx, sql, name).Scan(&executed); err != nil {
+		return false, errors.NewMigrationError("Failed to check seeder", err.Error(), "").WithCause(err)
+	}
+	return executed, nil
+}
+
+// RecordSeeder records a run-once seeder so later runs skip it
+func (t *Tracker) RecordSeeder(ctx context.Context, seeder *Seeder, executionTime time.Duration) error {
+	sql := fmt.Sprintf(`
+		INSERT INTO %s (seeder, executed_at, execution_time, checksum, run_id)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''))
+	`, pgx.Identifier{t.config.GetSeedersTable()}.Sanitize())
+
+	_, err := t.conn.Conn().Exec(ctx, sql,
+		seeder.Name,
+		time.Now(),
+		int(executionTime.Milliseconds()),
+		seeder.Checksum,
+		t.runID,
+	)
+	if err != nil {
+		return errors.NewMigrationError("Failed to record seeder", err.Error(), "").WithCause(err)
 	}
 
+	t.logger.Debug("Tracker", fmt.Sprintf("Recorded seeder %s", seeder.Name))
 	return nil
 }
 
@@ -128,7 +176,7 @@ func (t *Tracker) GetLastBatch(ctx context.Context) (int, error) {
 	var lastBatch int
 	err := t.conn.QueryRow(ctx, sql).Scan(&lastBatch)
 	if err != nil {
-		return 0, errors.NewMigrationError("Failed to get last batch", err.Error(), "")
+		return 0, errors.NewMigrationError("Failed to get last batch", err.Error(), "").WithCause(err)
 	}
 
 	return lastBatch, nil
@@ -137,7 +185,7 @@ func (t *Tracker) GetLastBatch(ctx context.Context) (int, error) {
 // GetMigrationsByBatch returns migrations from a specific batch
 func (t *Tracker) GetMigrationsByBatch(ctx context.Context, batch int) ([]*Migration, error) {
 	sql := fmt.Sprintf(`
-		SELECT id, migration, batch, executed_at, execution_time, checksum
+		SELECT id, migration, batch, executed_at, execution_time, checksum, COALESCE(run_id, '')
 		FROM %s
 		WHERE batch = $1
 		ORDER BY id DESC
@@ -145,7 +193,7 @@ func (t *Tracker) GetMigrationsByBatch(ctx context.Context, batch int) ([]*Migra
 
 	rows, err := t.conn.Query(ctx, sql, batch)
 	if err != nil {
-		return nil, errors.NewMigrationError("Failed to get migrations by batch", err.Error(), "")
+		return nil, errors.NewMigrationError("Failed to get migrations by batch", err.Error(), "").WithCause(err)
 	}
 	defer rows.Close()
 
@@ -159,9 +207,10 @@ func (t *Tracker) GetMigrationsByBatch(ctx context.Context, batch int) ([]*Migra
 			&migration.ExecutedAt,
 			&migration.ExecutionTime,
 			&migration.Checksum,
+			&migration.RunID,
 		)
 		if err != nil {
-			return nil, errors.NewMigrationError("Failed to scan migration row", err.Error(), "")
+			return nil, errors.NewMigrationError("Failed to scan migration row", err.Error(), "").WithCause(err)
 		}
 		migrations = append(migrations, migration)
 	}
@@ -204,11 +253,11 @@ func (t *Tracker) GetMigrationStatus(ctx context.Context, allMigrations []*Migra
 
 // MigrationStatus represents the status of a migration
 type MigrationStatus struct {
-	Migration     *Migration `json:"migration"`
-	Executed      bool       `json:"executed"`
-	ExecutedAt    time.Time  `json:"executed_at"`
-	Batch         int        `json:"batch"`
-	ExecutionTime int        `json:"execution_time"`
+	Migration     *Migration `json:"migration" yaml:"migration"`
+	Executed      bool       `json:"executed" yaml:"executed"`
+	ExecutedAt    time.Time  `json:"executed_at" yaml:"executed_at"`
+	Batch         int        `json:"batch" yaml:"batch"`
+	ExecutionTime int        `json:"execution_time" yaml:"execution_time"`
 }
 
 // VerifyChecksum verifies that a migration file hasn't been modified
@@ -224,13 +273,14 @@ func (t *Tracker) VerifyChecksum(ctx context.Context, migration *Migration) erro
 			// Migration not executed yet, no need to verify
 			return nil
 		}
-		return errors.NewMigrationError("Failed to get stored checksum", err.Error(), migration.Name)
+		return errors.NewMigrationError("Failed to get stored checksum", err.Error(), migration.Name).WithCause(err)
 	}
 
 	if storedChecksum != migration.Checksum {
-		return errors.NewValidationError(
+		return errors.NewDriftError(
 			fmt.Sprintf("Migration %s has been modified", migration.Name),
 			fmt.Sprintf("stored checksum: %s, current checksum: %s", storedChecksum, migration.Checksum),
+			migration.Name,
 		)
 	}
 
@@ -240,14 +290,14 @@ func (t *Tracker) VerifyChecksum(ctx context.Context, migration *Migration) erro
 // GetMigrationHistory returns the complete migration history
 func (t *Tracker) GetMigrationHistory(ctx context.Context) ([]*Migration, error) {
 	sql := fmt.Sprintf(`
-		SELECT id, migration, batch, executed_at, execution_time, checksum
+		SELECT id, migration, batch, executed_at, execution_time, checksum, COALESCE(run_id, '')
 		FROM %s
 		ORDER BY executed_at DESC
 	`, pgx.Identifier{t.config.Migration.Table}.Sanitize())
 
 	rows, err := t.conn.Query(ctx, sql)
 	if err != nil {
-		return nil, errors.NewMigrationError("Failed to get migration history", err.Error(), "")
+		return nil, errors.NewMigrationError("Failed to get migration history", err.Error(), "").WithCause(err)
 	}
 	defer rows.Close()
 
@@ -261,9 +311,10 @@ func (t *Tracker) GetMigrationHistory(ctx context.Context) ([]*Migration, error)
 			&migration.ExecutedAt,
 			&migration.ExecutionTime,
 			&migration.Checksum,
+			&migration.RunID,
 		)
 		if err != nil {
-			return nil, errors.NewMigrationError("Failed to scan migration row", err.Error(), "")
+			return nil, errors.NewMigrationError("Failed to scan migration row", err.Error(), "").WithCause(err)
 		}
 		migrations = append(migrations, migration)
 	}
@@ -0,0 +1,117 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vorzela/vorm/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a supported output format
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+	FormatYAML  Format = "yaml"
+	FormatCSV   Format = "csv"
+)
+
+// ParseFormat validates a --output value
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case FormatTable, FormatJSON, FormatYAML, FormatCSV:
+		return format, nil
+	default:
+		return "", errors.NewValidationError("Invalid output format",
+			fmt.Sprintf("output must be one of: table, json, yaml, csv. Got: %s", value))
+	}
+}
+
+// IsMachineReadable returns true for formats meant to be consumed by scripts
+func (f Format) IsMachineReadable() bool {
+	return f != FormatTable
+}
+
+// Table is the tabular form of a result, used by the table and csv formats
+type Table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// AddRow appends a row to the table
+func (t *Table) AddRow(values ...string) {
+	t.Rows = append(t.Rows, values)
+}
+
+// Renderer writes command results to stdout in the selected format
+// JSON and YAML serialize the data value, table and CSV use its Table form
+type Renderer struct {
+	format Format
+	out    io.Writer
+}
+
+// NewRenderer creates a renderer for the given format
+func NewRenderer(format Format, out io.Writer) *Renderer {
+	return &Renderer{format: format, out: out}
+}
+
+// Format returns the renderer's output format
+func (r *Renderer) Format() Format {
+	return r.format
+}
+
+// Render writes data in the selected format
+func (r *Renderer) Render(data interface{}, table Table) error {
+	switch r.format {
+	case FormatJSON:
+		encoder := json.NewEncoder(r.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(r.out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(data); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case FormatCSV:
+		return r.renderCSV(table)
+	default:
+		return r.renderTable(table)
+	}
+}
+
+// renderTable writes aligned columns without truncating values
+func (r *Renderer) renderTable(table Table) error {
+	writer := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(table.Headers, "\t"))
+
+	separators := make([]string, len(table.Headers))
+	for i, header := range table.Headers {
+		separators[i] = strings.Repeat("-", len(header))
+	}
+	fmt.Fprintln(writer, strings.Join(separators, "\t"))
+
+	for _, row := range table.Rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
+
+// renderCSV writes the table as CSV with a header row
+func (r *Renderer) renderCSV(table Table) error {
+	writer := csv.NewWriter(r.out)
+	if err := writer.Write(table.Headers); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(table.Rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
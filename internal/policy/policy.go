@@ -0,0 +1,123 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/utils"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// destructiveCommands lose data and are refused when
+// production.disable_destructive_operations is set
+var destructiveCommands = map[string]bool{
+	"db drop":  true,
+	"db reset": true,
+	"reset":    true,
+	"fresh":    true,
+	"refresh":  true,
+}
+
+// Policy decides which commands may run in the configured environment
+// It is the single place that knows whether the environment is protected
+type Policy struct {
+	config   *config.Config
+	username string
+	hostname string
+}
+
+// New creates the policy for cfg, identifying the current OS user and host
+func New(cfg *config.Config) *Policy {
+	return &Policy{
+		config:   cfg,
+		username: utils.GetUsername(),
+		hostname: utils.GetHostname(),
+	}
+}
+
+// Protected returns true if the environment is covered by the production policy
+func (p *Policy) Protected() bool {
+	return p.config.IsProduction()
+}
+
+// RequiresConfirmation returns true if destructive commands need the
+// production confirmation (typing YES, or --i-know-this-is-production)
+func (p *Policy) RequiresConfirmation() bool {
+	return p.Protected() && p.config.Production.RequireConfirmation
+}
+
+// IsDestructive returns true if command drops data
+func IsDestructive(command string) bool {
+	return destructiveCommands[command]
+}
+
+// Check returns a permission error if command may not run
+// command is the command path without the binary name, e.g. "db drop"
+func (p *Policy) Check(command string) error {
+	if !p.Protected() {
+		return nil
+	}
+
+	rules := p.config.Production
+	env := p.config.Environment
+
+	if len(rules.AllowedUsers) > 0 && !p.userAllowed(rules.AllowedUsers) {
+		return errors.NewPermissionError(
+			fmt.Sprintf("User '%s' may not run vorm in %s", p.username, env),
+			"Add the user to production.allowed_users",
+		)
+	}
+
+	if len(rules.AllowedHosts) > 0 && !contains(rules.AllowedHosts, p.hostname) {
+		return errors.NewPermissionError(
+			fmt.Sprintf("Host '%s' may not run vorm in %s", p.hostname, env),
+			"Add the host to production.allowed_hosts",
+		)
+	}
+
+	if contains(rules.DeniedCommands, command) {
+		return errors.NewPermissionError(
+			fmt.Sprintf("Command '%s' is denied in %s", command, env),
+			"The command is listed in production.denied_commands",
+		)
+	}
+
+	if len(rules.AllowedCommands) > 0 && !contains(rules.AllowedCommands, command) {
+		return errors.NewPermissionError(
+			fmt.Sprintf("Command '%s' is not allowed in %s", command, env),
+			"Only commands listed in production.allowed_commands may run",
+		)
+	}
+
+	if rules.DisableDestructiveOperations && IsDestructive(command) {
+		return errors.NewPermissionError(
+			fmt.Sprintf("Command '%s' is disabled in %s", command, env),
+			"This is a safety measure to prevent accidental data loss (production.disable_destructive_operations)",
+		)
+	}
+
+	return nil
+}
+
+// userAllowed matches the user with or without a Windows domain prefix
+func (p *Policy) userAllowed(allowed []string) bool {
+	name := p.username
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		if contains(allowed, name) {
+			return true
+		}
+		name = name[i+1:]
+	}
+	return contains(allowed, name)
+}
+
+// contains reports whether list holds value, ignoring case
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
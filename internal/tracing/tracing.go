@@ -0,0 +1,151 @@
+package tracing
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/vorzela/vorm/internal/buildinfo"
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// instrumentationName identifies the spans created by vorm
+const instrumentationName = "github.com/vorzela/vorm"
+
+// Attributes set on vorm's spans
+const (
+	Command        = attribute.Key("vorm.command")
+	RunID          = attribute.Key("vorm.run_id")
+	Environment    = attribute.Key("vorm.environment")
+	Migration      = attribute.Key("vorm.migration")
+	Direction      = attribute.Key("vorm.direction")
+	Batch          = attribute.Key("vorm.batch")
+	Seeder         = attribute.Key("vorm.seeder")
+	StatementIndex = attribute.Key("vorm.statement_index")
+	StatementLine  = attribute.Key("vorm.statement_line")
+	DBSystem       = attribute.Key("db.system")
+	DBName         = attribute.Key("db.name")
+	SQLState       = attribute.Key("db.response.status_code") // PostgreSQL SQLSTATE of a failed span
+)
+
+// Provider exports the spans of vorm runs as configured by the tracing section
+type Provider struct {
+	*sdktrace.TracerProvider
+	file *os.File // Trace file of the file exporter
+}
+
+// NewProvider creates a tracer provider for cfg.Tracing.Exporter
+// The stdout exporter writes spans to stdout, which the CLI passes as
+// os.Stderr to keep its own stdout for results. Spans are batched; call
+// Shutdown before exiting to flush them
+func NewProvider(ctx context.Context, cfg *config.Config, stdout io.Writer) (*Provider, error) {
+	provider := &Provider{}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Tracing.Exporter {
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if cfg.Tracing.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Tracing.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(stdout), stdouttrace.WithPrettyPrint())
+	case "file":
+		provider.file, err = os.OpenFile(cfg.Tracing.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, errors.NewFileError("Failed to open trace file", err.Error()).WithCause(err)
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(provider.file))
+	default:
+		return nil, errors.NewValidationError("Invalid tracing exporter", fmt.Sprintf("exporter must be one of: otlp, stdout, file. Got: %s", cfg.Tracing.Exporter))
+	}
+	if err != nil {
+		if provider.file != nil {
+			provider.file.Close()
+		}
+		return nil, errors.NewValidationError("Failed to create trace exporter", err.Error()).WithCause(err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.Tracing.ServiceName),
+		attribute.String("service.version", buildinfo.Version),
+		attribute.String("deployment.environment", cfg.Environment),
+	))
+	if err != nil {
+		res = resource.Default()
+	}
+
+	provider.TracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	return provider, nil
+}
+
+// Shutdown flushes pending spans and closes the exporter
+func (p *Provider) Shutdown(ctx context.Context) error {
+	err := p.TracerProvider.Shutdown(ctx)
+	if p.file != nil {
+		if closeErr := p.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return errors.NewConnectionError("Failed to export traces", err.Error()).WithCause(err)
+	}
+	return nil
+}
+
+// Tracer returns vorm's tracer from provider, or from the global provider if nil
+func Tracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(instrumentationName, trace.WithInstrumentationVersion(buildinfo.Version))
+}
+
+// ContextFromEnv returns ctx with the remote parent from the TRACEPARENT and
+// TRACESTATE environment variables, so a CI pipeline that traces its jobs
+// sees the migration in its trace
+// ctx is returned unchanged if it already carries a span
+func ContextFromEnv(ctx context.Context) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	carrier := propagation.MapCarrier{
+		"traceparent": os.Getenv("TRACEPARENT"),
+		"tracestate":  os.Getenv("TRACESTATE"),
+	}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
+
+// End marks span as failed if err is set, with the SQLSTATE of a failed
+// statement, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		var migrationErr *errors.MigrationError
+		if stderrors.As(err, &migrationErr) && migrationErr.SQLState != "" {
+			span.SetAttributes(SQLState.String(migrationErr.SQLState))
+		}
+	}
+	span.End()
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"os"
+	"os/user"
 	"path/filepath"
 	"runtime"
 
@@ -25,7 +26,7 @@ func EnsureDirectoryExists(path string) error {
 
 	// Create directory with proper permissions
 	if err := os.MkdirAll(path, 0755); err != nil {
-		return errors.NewFileError("Failed to create directory", err.Error())
+		return errors.NewFileError("Failed to create directory", err.Error()).WithCause(err)
 	}
 
 	return nil
@@ -48,7 +49,7 @@ func IsWritableDirectory(path string) error {
 	tempFile := filepath.Join(path, ".vorm_write_test")
 	file, err := os.Create(tempFile)
 	if err != nil {
-		return errors.NewFileError("Directory is not writable", err.Error())
+		return errors.NewFileError("Directory is not writable", err.Error()).WithCause(err)
 	}
 
 	// Clean up
@@ -62,7 +63,7 @@ func IsWritableDirectory(path string) error {
 func GetWorkingDirectory() (string, error) {
 	wd, err := os.Getwd()
 	if err != nil {
-		return "", errors.NewFileError("Failed to get working directory", err.Error())
+		return "", errors.NewFileError("Failed to get working directory", err.Error()).WithCause(err)
 	}
 	return wd, nil
 }
@@ -76,7 +77,7 @@ func JoinPath(elements ...string) string {
 func GetAbsolutePath(path string) (string, error) {
 	abs, err := filepath.Abs(path)
 	if err != nil {
-		return "", errors.NewFileError("Failed to get absolute path", err.Error())
+		return "", errors.NewFileError("Failed to get absolute path", err.Error()).WithCause(err)
 	}
 	return abs, nil
 }
@@ -90,7 +91,7 @@ func IsAbsolutePath(path string) bool {
 func GetHomeDirectory() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return "", errors.NewFileError("Failed to get home directory", err.Error())
+		return "", errors.NewFileError("Failed to get home directory", err.Error()).WithCause(err)
 	}
 	return home, nil
 }
@@ -106,13 +107,13 @@ func CreateFile(filename, content string) error {
 	// Create file
 	file, err := os.Create(filename)
 	if err != nil {
-		return errors.NewFileError("Failed to create file", err.Error())
+		return errors.NewFileError("Failed to create file", err.Error()).WithCause(err)
 	}
 	defer file.Close()
 
 	// Write content
 	if _, err := file.WriteString(content); err != nil {
-		return errors.NewFileError("Failed to write file content", err.Error())
+		return errors.NewFileError("Failed to write file content", err.Error()).WithCause(err)
 	}
 
 	return nil
@@ -122,7 +123,7 @@ func CreateFile(filename, content string) error {
 func ReadFile(filename string) (string, error) {
 	content, err := os.ReadFile(filename)
 	if err != nil {
-		return "", errors.NewFileError("Failed to read file", err.Error())
+		return "", errors.NewFileError("Failed to read file", err.Error()).WithCause(err)
 	}
 	return string(content), nil
 }
@@ -148,3 +149,17 @@ func IsWindows() bool {
 func IsUnix() bool {
 	return runtime.GOOS == "linux" || runtime.GOOS == "darwin" || runtime.GOOS == "freebsd"
 }
+
+// GetUsername returns the name of the OS user running vorm
+func GetUsername() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return ""
+}
+
+// GetHostname returns the host name reported by the kernel
+func GetHostname() string {
+	hostname, _ := os.Hostname()
+	return hostname
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"crypto/rand"
 	"fmt"
 	"regexp"
 	"strings"
@@ -242,3 +243,17 @@ func RemoveDuplicates(slice []string) []string {
 
 	return result
 }
+
+// GenerateRunID generates a random UUID (version 4) identifying a single run
+// It correlates log events with the tracking rows written by the same run
+func GenerateRunID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// Fall back to a time-based identifier if the system RNG fails
+		return fmt.Sprintf("run-%d", time.Now().UnixNano())
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -1,17 +1,71 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrorType classifies a MigrationError
+type ErrorType string
+
+// Error types
+const (
+	TypeConnection  ErrorType = "connection"
+	TypeMigration   ErrorType = "migration"
+	TypeValidation  ErrorType = "validation"
+	TypeFile        ErrorType = "file"
+	TypePermission  ErrorType = "permission"
+	TypeLockTimeout ErrorType = "lock-timeout"
+	TypeDrift       ErrorType = "drift"
+	TypeInterrupted ErrorType = "interrupted"
+)
+
+// Sentinel errors matching every MigrationError of the same type
+// Use errors.Is(err, errors.ErrConnection) instead of comparing Type
+var (
+	ErrConnection  = stderrors.New("connection error")
+	ErrMigration   = stderrors.New("migration error")
+	ErrValidation  = stderrors.New("validation error")
+	ErrFile        = stderrors.New("file error")
+	ErrPermission  = stderrors.New("permission error")
+	ErrLockTimeout = stderrors.New("lock timeout")
+	ErrDrift       = stderrors.New("migration drift")
+	ErrInterrupted = stderrors.New("interrupted")
 )
 
+var sentinels = map[ErrorType]error{
+	TypeConnection:  ErrConnection,
+	TypeMigration:   ErrMigration,
+	TypeValidation:  ErrValidation,
+	TypeFile:        ErrFile,
+	TypePermission:  ErrPermission,
+	TypeLockTimeout: ErrLockTimeout,
+	TypeDrift:       ErrDrift,
+	TypeInterrupted: ErrInterrupted,
+}
+
 // MigrationError represents all types of migration-related errors
 type MigrationError struct {
-	Type      string    // "connection", "migration", "validation", "file", "permission"
+	Type      ErrorType // Error classification
 	Message   string    // Human-readable message
 	Details   string    // Technical details
 	Migration string    // Migration name (if applicable)
 	Timestamp time.Time // When error occurred
+	SQLState  string    // PostgreSQL error code (if the server reported one)
+	Statement int       // 1-based index of the failing SQL statement (if applicable)
+	Err       error     // Underlying error (if any), e.g. a *pgconn.PgError
+	Location  *Location // Failing statement in the migration file (if known)
+}
+
+// Location points at a failing statement in a migration file
+type Location struct {
+	File    string   // Path of the migration file
+	Line    int      // 1-based line of the error, or of the statement if the position is unknown
+	Column  int      // 1-based column of the error, 0 if unknown
+	Excerpt []string // Statement lines up to and including Line
 }
 
 func (e *MigrationError) Error() string {
@@ -21,10 +75,41 @@ func (e *MigrationError) Error() string {
 	return fmt.Sprintf("[%s] %s: %s", e.Type, e.Message, e.Details)
 }
 
+// Unwrap returns the underlying error
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel for this error's type
+func (e *MigrationError) Is(target error) bool {
+	sentinel, ok := sentinels[e.Type]
+	return ok && target == sentinel
+}
+
+// WithCause records err as the underlying error
+// SQLState is filled in when err carries a PostgreSQL error
+func (e *MigrationError) WithCause(err error) *MigrationError {
+	e.Err = err
+	if pgErr := e.PgError(); pgErr != nil && e.SQLState == "" {
+		e.SQLState = pgErr.Code
+	}
+	return e
+}
+
+// PgError returns the PostgreSQL error reported by the server, if any
+// It gives access to the detail, hint, constraint name and position
+func (e *MigrationError) PgError() *pgconn.PgError {
+	var pgErr *pgconn.PgError
+	if stderrors.As(e.Err, &pgErr) {
+		return pgErr
+	}
+	return nil
+}
+
 // NewConnectionError creates a database connection error
 func NewConnectionError(message, details string) *MigrationError {
 	return &MigrationError{
-		Type:      "connection",
+		Type:      TypeConnection,
 		Message:   message,
 		Details:   details,
 		Timestamp: time.Now(),
@@ -34,7 +119,7 @@ func NewConnectionError(message, details string) *MigrationError {
 // NewMigrationError creates a migration execution error
 func NewMigrationError(message, details, migration string) *MigrationError {
 	return &MigrationError{
-		Type:      "migration",
+		Type:      TypeMigration,
 		Message:   message,
 		Details:   details,
 		Migration: migration,
@@ -45,7 +130,7 @@ func NewMigrationError(message, details, migration string) *MigrationError {
 // NewValidationError creates a configuration/file validation error
 func NewValidationError(message, details string) *MigrationError {
 	return &MigrationError{
-		Type:      "validation",
+		Type:      TypeValidation,
 		Message:   message,
 		Details:   details,
 		Timestamp: time.Now(),
@@ -55,7 +140,7 @@ func NewValidationError(message, details string) *MigrationError {
 // NewFileError creates a file system operation error
 func NewFileError(message, details string) *MigrationError {
 	return &MigrationError{
-		Type:      "file",
+		Type:      TypeFile,
 		Message:   message,
 		Details:   details,
 		Timestamp: time.Now(),
@@ -65,7 +150,38 @@ func NewFileError(message, details string) *MigrationError {
 // NewPermissionError creates a database permission error
 func NewPermissionError(message, details string) *MigrationError {
 	return &MigrationError{
-		Type:      "permission",
+		Type:      TypePermission,
+		Message:   message,
+		Details:   details,
+		Timestamp: time.Now(),
+	}
+}
+
+// NewLockTimeoutError creates an error for a migration lock that could not be acquired in time
+func NewLockTimeoutError(message, details string) *MigrationError {
+	return &MigrationError{
+		Type:      TypeLockTimeout,
+		Message:   message,
+		Details:   details,
+		Timestamp: time.Now(),
+	}
+}
+
+// NewDriftError creates an error for a database that no longer matches the migration files
+func NewDriftError(message, details, migration string) *MigrationError {
+	return &MigrationError{
+		Type:      TypeDrift,
+		Message:   message,
+		Details:   details,
+		Migration: migration,
+		Timestamp: time.Now(),
+	}
+}
+
+// NewInterruptedError creates an error for a run stopped by a signal or deadline
+func NewInterruptedError(message, details string) *MigrationError {
+	return &MigrationError{
+		Type:      TypeInterrupted,
 		Message:   message,
 		Details:   details,
 		Timestamp: time.Now(),
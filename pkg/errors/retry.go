@@ -0,0 +1,51 @@
+package errors
+
+import (
+	stderrors "errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// retryableSQLStates lists SQLSTATE codes worth retrying as-is
+var retryableSQLStates = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"55P03": true, // lock_not_available
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+// IsRetryable reports whether err is transient, so running the same
+// operation again may succeed
+// This covers lost connections, lock timeouts, serialization failures
+// and deadlocks; SQL and validation errors are never retryable
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if stderrors.As(err, &pgErr) {
+		// Class 08 (connection exception) and 53 (insufficient resources)
+		if strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "53") {
+			return true
+		}
+		return retryableSQLStates[pgErr.Code]
+	}
+
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	var migrationErr *MigrationError
+	if stderrors.As(err, &migrationErr) {
+		switch migrationErr.Type {
+		case TypeConnection, TypeLockTimeout:
+			return true
+		}
+	}
+
+	return false
+}
@@ -6,97 +6,247 @@ import (
 	"github.com/vorzela/vorm/internal/config"
 	"github.com/vorzela/vorm/internal/logger"
 	"github.com/vorzela/vorm/internal/migration"
+	"github.com/vorzela/vorm/internal/policy"
+	"github.com/vorzela/vorm/internal/tracing"
 	"github.com/vorzela/vorm/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client is the main VORM client for programmatic access
 type Client struct {
-	config  *config.Config
-	logger  *logger.Logger
-	manager *migration.Manager
+	config    *config.Config
+	logger    logger.Logger
+	console   *logger.ConsoleLogger // Default logger, owned and closed by the client
+	manager   *migration.Manager
+	source    migration.Source
+	policy    *policy.Policy
+	observers []Observer
+	metrics   *Metrics
+
+	seeders      []*migration.Seeder // Go seeders
+	seederSource migration.Source
+
+	schemaMigrations []schemaMigration // Compiled and added to the manager by NewClient
+
+	tracerProvider trace.TracerProvider
+	tracing        *tracing.Provider // Provider for the configured exporter, owned and shut down by the client
 }
 
 // NewClient creates a new VORM client
-func NewClient(configPath string) (*Client, error) {
+func NewClient(configPath string, opts ...Option) (_ *Client, err error) {
+	client := &Client{}
+	for _, opt := range opts {
+		opt(client)
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		return nil, errors.NewValidationError("Failed to load configuration", err.Error())
+		return nil, errors.NewValidationError("Failed to load configuration", err.Error()).WithCause(err)
 	}
 
 	// Validate configuration
 	validator := config.NewValidator(cfg)
+	if client.source != nil {
+		// Migrations supplied by the caller don't need a migrations directory
+		validator.SkipMigrationsDirectory()
+	}
 	if err := validator.Validate(); err != nil {
 		return nil, err
 	}
 
-	// Create logger
-	logger, err := logger.NewLogger(cfg)
-	if err != nil {
-		return nil, errors.NewValidationError("Failed to create logger", err.Error())
+	// Create the console logger unless the caller supplied one
+	if client.logger == nil {
+		console, loggerErr := logger.NewLogger(cfg)
+		if loggerErr != nil {
+			return nil, errors.NewValidationError("Failed to create logger", loggerErr.Error()).WithCause(loggerErr)
+		}
+		client.console = console
+		client.logger = console
+
+		// Don't leak the log file and its lock file if the client isn't returned
+		defer func() {
+			if err != nil {
+				console.Close()
+			}
+		}()
 	}
 
 	// Create migration manager
-	manager, err := migration.NewManager(cfg, logger)
+	manager, err := migration.NewManager(cfg, client.logger)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{
-		config:  cfg,
-		logger:  logger,
-		manager: manager,
-	}, nil
+	if client.source != nil {
+		manager.SetSource(client.source)
+	}
+	if err := client.addSchemaMigrations(manager); err != nil {
+		return nil, err
+	}
+	if err := addGoMigrations(manager); err != nil {
+		return nil, err
+	}
+	if client.seederSource != nil {
+		manager.SetSeederSource(client.seederSource)
+	}
+	for _, seeder := range client.seeders {
+		manager.AddSeeder(seeder)
+	}
+	for _, observer := range client.observers {
+		manager.AddObserver(observerAdapter{observer: observer})
+	}
+	if client.metrics != nil {
+		manager.AddObserver(client.metrics.recorder.Observer(cfg.Environment, cfg.Database.Database))
+	}
+
+	client.config = cfg
+	client.manager = manager
+	client.policy = policy.New(cfg)
+
+	if err := client.setupTracing(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// startCommand names the running command and checks it against the
+// environment policy, like the CLI does
+func (c *Client) startCommand(command string) error {
+	c.manager.SetCommand(command)
+	return c.policy.Check(command)
 }
 
 // CreateMigration creates a new migration file
-func (c *Client) CreateMigration(name string) (*migration.Migration, error) {
-	return c.manager.CreateMigration(name)
+func (c *Client) CreateMigration(name string) (*MigrationInfo, error) {
+	m, err := c.manager.CreateMigration(name)
+	if err != nil {
+		return nil, err
+	}
+	info := newMigrationInfo(m)
+	return &info, nil
 }
 
 // Migrate runs pending migrations
 func (c *Client) Migrate(ctx context.Context) error {
+	if err := c.startCommand("migrate"); err != nil {
+		return err
+	}
 	return c.manager.RunMigrations(ctx, 0)
 }
 
 // MigrateSteps runs a specific number of pending migrations
 func (c *Client) MigrateSteps(ctx context.Context, steps int) error {
+	if err := c.startCommand("migrate"); err != nil {
+		return err
+	}
 	return c.manager.RunMigrations(ctx, steps)
 }
 
 // Rollback rolls back the last batch of migrations
 func (c *Client) Rollback(ctx context.Context) error {
+	if err := c.startCommand("rollback"); err != nil {
+		return err
+	}
 	return c.manager.RollbackMigrations(ctx, 0)
 }
 
 // RollbackSteps rolls back a specific number of migrations
 func (c *Client) RollbackSteps(ctx context.Context, steps int) error {
+	if err := c.startCommand("rollback"); err != nil {
+		return err
+	}
 	return c.manager.RollbackSteps(ctx, steps)
 }
 
 // Reset rolls back all migrations
 func (c *Client) Reset(ctx context.Context) error {
+	if err := c.startCommand("reset"); err != nil {
+		return err
+	}
 	return c.manager.ResetAllMigrations(ctx)
 }
 
 // Fresh drops all tables and re-runs all migrations
 func (c *Client) Fresh(ctx context.Context) error {
+	if err := c.startCommand("fresh"); err != nil {
+		return err
+	}
 	return c.manager.FreshMigrations(ctx)
 }
 
 // Status returns the status of all migrations
-func (c *Client) Status(ctx context.Context) ([]migration.MigrationStatus, error) {
-	return c.manager.GetMigrationStatus(ctx)
+func (c *Client) Status(ctx context.Context) ([]Status, error) {
+	statuses, err := c.manager.GetMigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Status, 0, len(statuses))
+	pending := 0
+	for _, status := range statuses {
+		result = append(result, newStatus(status))
+		if !status.Executed {
+			pending++
+		}
+	}
+
+	if c.metrics != nil {
+		c.metrics.recorder.SetPending(c.config.Environment, c.config.Database.Database, pending)
+	}
+	return result, nil
 }
 
 // List returns all available migrations
-func (c *Client) List() ([]*migration.Migration, error) {
-	return c.manager.ListMigrations()
+func (c *Client) List() ([]MigrationInfo, error) {
+	migrations, err := c.manager.ListMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return newMigrationInfos(migrations), nil
+}
+
+// History returns migration execution history, most recent first
+func (c *Client) History(ctx context.Context) ([]HistoryEntry, error) {
+	history, err := c.manager.GetMigrationHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]HistoryEntry, 0, len(history))
+	for _, m := range history {
+		entries = append(entries, newHistoryEntry(m))
+	}
+	return entries, nil
+}
+
+// PlanMigrate returns the pending migrations Migrate or MigrateSteps would run
+// A steps value of 0 plans all pending migrations
+func (c *Client) PlanMigrate(ctx context.Context, steps int) (*Plan, error) {
+	migrations, batch, err := c.manager.PlanMigrations(ctx, steps)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Plan{
+		Direction:  DirectionUp,
+		Batch:      batch,
+		Migrations: newMigrationInfos(migrations),
+	}, nil
 }
 
-// History returns migration execution history
-func (c *Client) History(ctx context.Context) ([]*migration.Migration, error) {
-	return c.manager.GetMigrationHistory(ctx)
+// PlanRollback returns the migrations Rollback or RollbackSteps would revert
+// A steps value of 0 plans a rollback of the last batch
+func (c *Client) PlanRollback(ctx context.Context, steps int) (*Plan, error) {
+	migrations, err := c.manager.PlanRollback(ctx, steps)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Plan{
+		Direction:  DirectionDown,
+		Migrations: newMigrationInfos(migrations),
+	}, nil
 }
 
 // Close closes the client and cleans up resources
@@ -104,10 +254,17 @@ func (c *Client) Close(ctx context.Context) error {
 	if c.manager != nil {
 		c.manager.Close(ctx)
 	}
-	if c.logger != nil {
-		return c.logger.Close()
+
+	var err error
+	if c.tracing != nil {
+		err = c.tracing.Shutdown(ctx)
+	}
+	if c.console != nil {
+		if closeErr := c.console.Close(); err == nil {
+			err = closeErr
+		}
 	}
-	return nil
+	return err
 }
 
 // GetConfig returns the current configuration
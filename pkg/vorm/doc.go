@@ -0,0 +1,12 @@
+// Package vorm provides programmatic access to VORM migrations.
+//
+// The Client type, the Migrator interface and the result types defined in
+// this package (MigrationInfo, Status, HistoryEntry, Plan, and the Observer
+// interface with its event types) form the public
+// API of VORM. They follow semantic versioning: fields and methods are only
+// added in minor releases and never removed or changed outside a major
+// release. Migrator is the exception: its method set only changes in a
+// major release, so fakes implementing it keep compiling. Operations added
+// to Client later get interfaces of their own. Code under internal/ carries
+// no such guarantee.
+package vorm
@@ -0,0 +1,13 @@
+package vorm
+
+import (
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// IsRetryable reports whether an error returned by a Client method is
+// transient, such as a dropped connection, lock timeout or deadlock
+// Use errors.Is with the sentinels in pkg/errors to check the error type and
+// (*errors.MigrationError).PgError for the PostgreSQL detail and hint
+func IsRetryable(err error) bool {
+	return errors.IsRetryable(err)
+}
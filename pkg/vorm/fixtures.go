@@ -0,0 +1,64 @@
+package vorm
+
+import (
+	"context"
+
+	"github.com/vorzela/vorm/internal/database"
+	"github.com/vorzela/vorm/internal/fixtures"
+)
+
+// FixtureOptions controls LoadFixtures
+type FixtureOptions struct {
+	Truncate bool // Empty the fixture tables first, restarting their sequences
+}
+
+// FixtureResult reports the rows loaded into a table from one file
+type FixtureResult struct {
+	Table  string `json:"table"`
+	Source string `json:"source"` // Fixture file
+	Rows   int64  `json:"rows"`
+}
+
+// LoadFixtures inserts YAML, JSON and CSV fixtures into their tables in one
+// transaction, parents before children according to their foreign keys.
+// paths may be files, directories or glob patterns
+func (c *Client) LoadFixtures(ctx context.Context, paths []string, opts FixtureOptions) ([]FixtureResult, error) {
+	if err := c.startCommand("fixtures:load"); err != nil {
+		return nil, err
+	}
+
+	files, err := fixtures.ExpandPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := fixtures.ReadFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := database.NewConnection(c.config)
+	if err := conn.Connect(ctx); err != nil {
+		return nil, err
+	}
+	defer conn.Close(ctx)
+
+	results, err := fixtures.NewLoader(conn.Conn(), fixtures.Options{Truncate: opts.Truncate}).Load(ctx, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make([]FixtureResult, 0, len(results))
+	for _, result := range results {
+		loaded = append(loaded, FixtureResult(result))
+	}
+	return loaded, nil
+}
+
+// FixtureLoader is implemented by Client; depend on it next to Migrator to
+// substitute a fake that loads fixtures in tests
+type FixtureLoader interface {
+	LoadFixtures(ctx context.Context, paths []string, opts FixtureOptions) ([]FixtureResult, error)
+}
+
+// Ensure Client satisfies FixtureLoader
+var _ FixtureLoader = (*Client)(nil)
@@ -0,0 +1,91 @@
+package vorm
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/vorzela/vorm/internal/logger"
+)
+
+// Logger receives VORM's progress messages
+// The category groups related messages, e.g. "Migration" or "Database"
+type Logger interface {
+	Debug(category, message string)
+	Info(category, message string)
+	Success(category, message string)
+	Warning(category, message string)
+	Error(category, message string)
+}
+
+// WithLogger replaces the default colored console logger
+// Use NopLogger for silent operation or NewSlogLogger to forward messages
+// to the structured logging of the embedding service
+func WithLogger(l Logger) Option {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// NopLogger returns a logger that discards all messages
+func NopLogger() Logger {
+	return logger.NewNopLogger()
+}
+
+// slogLogger forwards messages to a log/slog logger
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a logger that writes to l
+// The category is attached as the "category" attribute and success messages
+// are logged at info level with outcome=success
+func NewSlogLogger(l *slog.Logger) Logger {
+	return &slogLogger{logger: l}
+}
+
+func (l *slogLogger) Debug(category, message string) {
+	l.log(slog.LevelDebug, category, message)
+}
+
+func (l *slogLogger) Info(category, message string) {
+	l.log(slog.LevelInfo, category, message)
+}
+
+func (l *slogLogger) Success(category, message string) {
+	l.log(slog.LevelInfo, category, message, slog.String("outcome", "success"))
+}
+
+func (l *slogLogger) Warning(category, message string) {
+	l.log(slog.LevelWarn, category, message)
+}
+
+func (l *slogLogger) Error(category, message string) {
+	l.log(slog.LevelError, category, message)
+}
+
+// LogFields writes a record with the structured fields as attributes
+func (l *slogLogger) LogFields(level logger.LogLevel, category, message string, fields logger.Fields) {
+	attrs := make([]slog.Attr, 0, len(fields)+1)
+	for key, value := range fields {
+		attrs = append(attrs, slog.Any(key, value))
+	}
+
+	switch level {
+	case logger.DEBUG:
+		l.log(slog.LevelDebug, category, message, attrs...)
+	case logger.INFO:
+		l.log(slog.LevelInfo, category, message, attrs...)
+	case logger.SUCCESS:
+		l.log(slog.LevelInfo, category, message, append(attrs, slog.String("outcome", "success"))...)
+	case logger.WARNING:
+		l.log(slog.LevelWarn, category, message, attrs...)
+	default:
+		l.log(slog.LevelError, category, message, attrs...)
+	}
+}
+
+// log writes a single record with the category attribute
+func (l *slogLogger) log(level slog.Level, category, message string, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{slog.String("category", category)}, attrs...)
+	l.logger.LogAttrs(context.Background(), level, message, attrs...)
+}
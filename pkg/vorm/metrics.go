@@ -0,0 +1,51 @@
+package vorm
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/vorzela/vorm/internal/metrics"
+)
+
+// Metrics collects Prometheus metrics from the runs of the clients it is given to:
+// migrations applied and rolled back, durations per migration, lock wait
+// time, failures by SQLSTATE and pending migrations
+//
+// A long-running service can serve them next to its own metrics:
+//
+//	m := vorm.NewMetrics()
+//	client, err := vorm.NewClient("", vorm.WithMetrics(m))
+//	http.Handle("/metrics/vorm", m)
+type Metrics struct {
+	recorder *metrics.Recorder
+}
+
+// NewMetrics creates an empty metrics collector
+func NewMetrics() *Metrics {
+	return &Metrics{recorder: metrics.NewRecorder()}
+}
+
+// WithMetrics records the client's runs in m
+// One Metrics can be shared by several clients; series are labelled with
+// the environment and database of each
+func WithMetrics(m *Metrics) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.recorder.ServeHTTP(w, r)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	return m.recorder.WriteTo(w)
+}
+
+// WriteTextfile atomically replaces path with the current metrics, for the
+// node_exporter textfile collector; path should end in .prom
+func (m *Metrics) WriteTextfile(path string) error {
+	return m.recorder.WriteTextfile(path)
+}
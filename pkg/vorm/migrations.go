@@ -0,0 +1,87 @@
+package vorm
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vorzela/vorm/internal/migration"
+	"github.com/vorzela/vorm/pkg/vorm/schema"
+)
+
+// MigrationFunc is the up or down step of a Go migration
+// It runs in the migration's transaction; returning an error rolls it back
+type MigrationFunc func(ctx context.Context, tx pgx.Tx) error
+
+// Register registers a Go migration for every client created afterwards,
+// typically from an init function next to the code it needs:
+//
+//	func init() {
+//		vorm.Register("2025_07_01_120000_backfill_slugs", backfillSlugs, nil)
+//	}
+//
+//	func backfillSlugs(ctx context.Context, tx pgx.Tx) error {
+//		rows, err := tx.Query(ctx, `SELECT id, title FROM posts WHERE slug IS NULL`)
+//		...
+//	}
+//
+// version is named like a migration file without extension; the migration
+// runs in timestamp order with the SQL files and is tracked in the same
+// table. Its checksum covers the version only, since code can't be hashed.
+// A nil down makes the migration irreversible: rolling it back fails.
+// Invalid or duplicate versions are reported by NewClient. vormtest
+// applies registered migrations to its test databases too
+func Register(version string, up, down MigrationFunc) {
+	migration.RegisterGoMigration(version, migration.MigrationFunc(up), migration.MigrationFunc(down))
+}
+
+// addGoMigrations registers the Go migrations with the manager
+func addGoMigrations(manager *migration.Manager) error {
+	migrations, err := migration.GoMigrations()
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		manager.AddMigration(m)
+	}
+	return nil
+}
+
+// schemaMigration is a migration written with the schema builder
+type schemaMigration struct {
+	version string
+	ops     []schema.Operation
+}
+
+// WithSchemaMigration registers a migration written with the schema
+// builder. version is named like a migration file without extension, and
+// the migration runs in timestamp order with the SQL migration files:
+//
+//	client, err := vorm.NewClient("", vorm.WithSchemaMigration("2025_07_01_120000_create_users",
+//		schema.Create("users", func(t *schema.Table) {
+//			t.UUID("id").Primary().Default("gen_random_uuid()")
+//			t.String("email").NotNull().Unique()
+//			t.Timestamps()
+//		}),
+//	))
+//
+// The Down SQL is derived from the operations. The checksum covers the
+// operations, so changing an applied builder migration is detected like
+// editing an applied file, while changes to the generated DDL are not
+func WithSchemaMigration(version string, ops ...schema.Operation) Option {
+	return func(c *Client) {
+		c.schemaMigrations = append(c.schemaMigrations, schemaMigration{version: version, ops: ops})
+	}
+}
+
+// addSchemaMigrations compiles the builder migrations and registers them
+// with the manager
+func (c *Client) addSchemaMigrations(manager *migration.Manager) error {
+	for _, registered := range c.schemaMigrations {
+		m, err := migration.NewSchemaMigration(registered.version, registered.ops...)
+		if err != nil {
+			return err
+		}
+		manager.AddMigration(m)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package vorm
+
+import (
+	"time"
+
+	"github.com/vorzela/vorm/internal/migration"
+)
+
+// Observer receives progress events while migrations run, e.g. to stream
+// progress to a UI or post to a chat channel
+// Callbacks run synchronously on the migrating goroutine and should return
+// quickly. Embed BaseObserver to implement only the callbacks you need
+type Observer interface {
+	// OnPlan is called once with the migrations a run is about to apply or
+	// revert; the plan is empty when there is nothing to do
+	OnPlan(plan Plan)
+	// OnMigrationStart is called before each migration is applied or rolled back
+	OnMigrationStart(event MigrationEvent)
+	// OnStatement is called after each statement of a migration succeeded
+	OnStatement(event StatementEvent)
+	// OnMigrationDone is called after an applied migration is committed
+	OnMigrationDone(event MigrationEvent)
+	// OnRollback is called after a rolled back migration is committed
+	OnRollback(event MigrationEvent)
+	// OnError is called when a run fails
+	OnError(event ErrorEvent)
+	// OnLockWait is called while another process holds the migration lock,
+	// and once more with Acquired set when this run gets it
+	OnLockWait(event LockEvent)
+}
+
+// MigrationEvent describes a migration being applied or rolled back
+type MigrationEvent struct {
+	Migration MigrationInfo
+	Direction Direction
+	Batch     int
+	Duration  time.Duration // Zero in OnMigrationStart
+}
+
+// StatementEvent describes a statement executed by a migration
+type StatementEvent struct {
+	Migration MigrationInfo
+	Direction Direction
+	Index     int // 1-based position within the Up or Down section
+	Total     int
+	SQL       string
+	Line      int // File line the statement starts on
+	Duration  time.Duration
+}
+
+// ErrorEvent describes a failed run
+type ErrorEvent struct {
+	Migration *MigrationInfo // nil if the run failed outside a migration, e.g. in a hook
+	Direction Direction
+	Batch     int
+	Err       error
+}
+
+// LockEvent reports a wait for the migration lock held by another process
+// The last event of a wait that ended with the lock taken has Acquired set
+type LockEvent struct {
+	Lock     string
+	Waited   time.Duration
+	Timeout  time.Duration // migration.lock_timeout, 0 waits indefinitely
+	Acquired bool
+}
+
+// WithObserver registers an observer for migration progress events
+// It can be given several times; observers are called in order, after the logger
+func WithObserver(o Observer) Option {
+	return func(c *Client) {
+		c.observers = append(c.observers, o)
+	}
+}
+
+// BaseObserver implements Observer with callbacks that do nothing
+type BaseObserver struct{}
+
+func (BaseObserver) OnPlan(Plan)                     {}
+func (BaseObserver) OnMigrationStart(MigrationEvent) {}
+func (BaseObserver) OnStatement(StatementEvent)      {}
+func (BaseObserver) OnMigrationDone(MigrationEvent)  {}
+func (BaseObserver) OnRollback(MigrationEvent)       {}
+func (BaseObserver) OnError(ErrorEvent)              {}
+func (BaseObserver) OnLockWait(LockEvent)            {}
+
+// observerAdapter converts internal events to their public form
+type observerAdapter struct {
+	observer Observer
+}
+
+func (a observerAdapter) OnPlan(event migration.PlanEvent) {
+	a.observer.OnPlan(Plan{
+		Direction:  Direction(event.Direction),
+		Batch:      event.Batch,
+		Migrations: newMigrationInfos(event.Migrations),
+	})
+}
+
+func (a observerAdapter) OnMigrationStart(event migration.MigrationEvent) {
+	a.observer.OnMigrationStart(newMigrationEvent(event))
+}
+
+func (a observerAdapter) OnStatement(event migration.StatementEvent) {
+	a.observer.OnStatement(StatementEvent{
+		Migration: newMigrationInfo(event.Migration),
+		Direction: Direction(event.Direction),
+		Index:     event.Index,
+		Total:     event.Total,
+		SQL:       event.Statement.SQL,
+		Line:      event.Statement.Line,
+		Duration:  event.Duration,
+	})
+}
+
+func (a observerAdapter) OnMigrationDone(event migration.MigrationEvent) {
+	a.observer.OnMigrationDone(newMigrationEvent(event))
+}
+
+func (a observerAdapter) OnRollback(event migration.MigrationEvent) {
+	a.observer.OnRollback(newMigrationEvent(event))
+}
+
+func (a observerAdapter) OnError(event migration.ErrorEvent) {
+	errorEvent := ErrorEvent{
+		Direction: Direction(event.Direction),
+		Batch:     event.Batch,
+		Err:       event.Err,
+	}
+	if event.Migration != nil {
+		info := newMigrationInfo(event.Migration)
+		errorEvent.Migration = &info
+	}
+	a.observer.OnError(errorEvent)
+}
+
+func (a observerAdapter) OnLockWait(event migration.LockEvent) {
+	a.observer.OnLockWait(LockEvent{
+		Lock:     event.Lock,
+		Waited:   event.Waited,
+		Timeout:  event.Timeout,
+		Acquired: event.Acquired,
+	})
+}
+
+// newMigrationEvent converts an internal migration event
+func newMigrationEvent(event migration.MigrationEvent) MigrationEvent {
+	return MigrationEvent{
+		Migration: newMigrationInfo(event.Migration),
+		Direction: Direction(event.Direction),
+		Batch:     event.Batch,
+		Duration:  event.Duration,
+	}
+}
@@ -0,0 +1,38 @@
+package vorm
+
+import (
+	"io/fs"
+
+	"github.com/vorzela/vorm/internal/migration"
+)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithMigrationsDir loads migrations from a directory on disk instead of
+// the directory from the configuration
+func WithMigrationsDir(dir string) Option {
+	return func(c *Client) {
+		c.source = migration.NewDirSource(dir)
+	}
+}
+
+// WithMigrationsFS loads migrations from root inside fsys
+// This allows a service to embed its migrations in the binary:
+//
+//	//go:embed migrations/*.sql
+//	var migrationsFS embed.FS
+//
+//	client, err := vorm.NewClient("", vorm.WithMigrationsFS(migrationsFS, "migrations"))
+func WithMigrationsFS(fsys fs.FS, root string) Option {
+	return func(c *Client) {
+		c.source = migration.NewFSSource(fsys, root)
+	}
+}
+
+// WithMigrationsArchive loads migrations from a tar.gz archive
+func WithMigrationsArchive(path string) Option {
+	return func(c *Client) {
+		c.source = migration.NewArchiveSource(path)
+	}
+}
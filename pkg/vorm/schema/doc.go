@@ -0,0 +1,22 @@
+// Package schema builds PostgreSQL DDL for migrations written in Go.
+//
+// A migration is a list of operations. Compile turns them into the Up SQL
+// and derives the Down SQL that undoes them, in reverse order:
+//
+//	up, down, err := schema.Compile(
+//		schema.Create("users", func(t *schema.Table) {
+//			t.UUID("id").Primary().Default("gen_random_uuid()")
+//			t.String("email").NotNull().Unique()
+//			t.Timestamps()
+//		}),
+//		schema.Alter("posts", func(t *schema.Table) {
+//			t.UUID("author_id").References("users", "id").OnDelete("CASCADE").Index()
+//			t.RenameColumn("body", "content")
+//		}),
+//	)
+//
+// Register the operations with vorm.WithSchemaMigration to run them with
+// the SQL migration files, ordered by timestamp. Names are used as given,
+// so quote them in the name itself if they need quoting. Raw covers
+// changes the builder doesn't, with an explicit Down.
+package schema
@@ -0,0 +1,138 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vorzela/vorm/internal/ddl"
+)
+
+// Operation is a schema change that knows how to undo itself
+type Operation interface {
+	// statements returns the Up statements and the Down statements that
+	// undo them, in the order they run
+	statements() (up, down []string, err error)
+
+	// spec returns the operation as a JSON-encodable value
+	spec() any
+}
+
+// Fingerprint returns a stable description of ops, independent of the SQL
+// Compile generates for them. Builder migrations are checksummed over it,
+// so improvements to the generated DDL don't show up as drift
+func Fingerprint(ops ...Operation) string {
+	specs := make([]any, 0, len(ops))
+	for _, op := range ops {
+		specs = append(specs, op.spec())
+	}
+	data, _ := json.Marshal(specs)
+	return string(data)
+}
+
+// Compile returns the Up SQL of ops and the Down SQL undoing them, which
+// runs the operations' Down statements in reverse order
+func Compile(ops ...Operation) (upSQL, downSQL string, err error) {
+	var up, down []string
+	for _, op := range ops {
+		opUp, opDown, err := op.statements()
+		if err != nil {
+			return "", "", err
+		}
+		up = append(up, opUp...)
+		down = append(opDown, down...)
+	}
+	return strings.Join(up, "\n"), strings.Join(down, "\n"), nil
+}
+
+// tableOperation creates or alters a table
+type tableOperation struct {
+	table *Table
+}
+
+// Create creates a table with the columns, indexes and foreign keys added
+// by build. Down drops the table and the enum types created for it
+func Create(name string, build func(t *Table)) Operation {
+	table := &Table{name: name, create: true}
+	build(table)
+	return tableOperation{table: table}
+}
+
+// Alter adds the columns, indexes and foreign keys added by build to an
+// existing table and renames columns. Down drops and renames them back
+func Alter(name string, build func(t *Table)) Operation {
+	table := &Table{name: name}
+	build(table)
+	return tableOperation{table: table}
+}
+
+func (o tableOperation) spec() any {
+	operation := "alter"
+	if o.table.create {
+		operation = "create"
+	}
+	return map[string]any{"op": operation, "table": o.table.name, "items": o.table.specs()}
+}
+
+func (o tableOperation) statements() (up, down []string, err error) {
+	if o.table.err != nil {
+		return nil, nil, o.table.err
+	}
+	if o.table.create {
+		up, down = o.table.createStatements()
+	} else {
+		up, down = o.table.alterStatements()
+	}
+	return up, down, nil
+}
+
+// renameOperation renames a table
+type renameOperation struct {
+	from, to string
+}
+
+// Rename renames a table; Down renames it back
+func Rename(from, to string) Operation {
+	return renameOperation{from: from, to: to}
+}
+
+func (o renameOperation) spec() any {
+	return map[string]any{"op": "rename", "from": o.from, "to": o.to}
+}
+
+func (o renameOperation) statements() (up, down []string, err error) {
+	up = []string{fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", o.from, ddl.Unqualified(o.to))}
+	down = []string{fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", ddl.QualifiedLike(o.from, o.to), ddl.Unqualified(o.from))}
+	return up, down, nil
+}
+
+// rawOperation runs SQL written by hand
+type rawOperation struct {
+	up, down string
+}
+
+// irreversible is the Down SQL of a Raw operation without down; it fails
+// the rollback like a Go migration registered without a down function
+const irreversible = `DO $$ BEGIN RAISE EXCEPTION 'schema.Raw step has no Down SQL and can''t be rolled back'; END $$;`
+
+// Raw runs up as is, with down as its Down SQL
+// An empty down makes the operation irreversible: rolling back the
+// migration fails instead of leaving the changes of up in place
+func Raw(up, down string) Operation {
+	return rawOperation{up: up, down: down}
+}
+
+func (o rawOperation) spec() any {
+	return map[string]any{"op": "raw", "up": o.up, "down": o.down}
+}
+
+func (o rawOperation) statements() (up, down []string, err error) {
+	if strings.TrimSpace(o.up) != "" {
+		up = []string{o.up}
+		down = []string{irreversible}
+	}
+	if strings.TrimSpace(o.down) != "" {
+		down = []string{o.down}
+	}
+	return up, down, nil
+}
@@ -0,0 +1,250 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/vorzela/vorm/internal/ddl"
+	"github.com/vorzela/vorm/pkg/errors"
+)
+
+// Table collects the columns, indexes and renames of Create and Alter
+type Table struct {
+	name   string
+	create bool
+	items  []any // *Column, ddl.Index and renameColumn, in the order added
+	err    *errors.MigrationError
+}
+
+// Column is a column added to a table; its methods set constraints and
+// return the column so calls can be chained
+type Column struct {
+	column ddl.Column
+}
+
+// renameColumn renames a column of an existing table
+type renameColumn struct {
+	from, to string
+}
+
+// Column adds a column of any PostgreSQL type, such as "TEXT[]"
+func (t *Table) Column(name, sqlType string) *Column {
+	column := &Column{column: ddl.Column{Name: name, Type: sqlType}}
+	t.items = append(t.items, column)
+	return column
+}
+
+// UUID adds a UUID column
+func (t *Table) UUID(name string) *Column {
+	return t.Column(name, "UUID")
+}
+
+// String adds a VARCHAR(255) column
+func (t *Table) String(name string) *Column {
+	return t.Column(name, "VARCHAR(255)")
+}
+
+// Text adds a TEXT column
+func (t *Table) Text(name string) *Column {
+	return t.Column(name, "TEXT")
+}
+
+// Integer adds an INTEGER column
+func (t *Table) Integer(name string) *Column {
+	return t.Column(name, "INTEGER")
+}
+
+// BigInteger adds a BIGINT column
+func (t *Table) BigInteger(name string) *Column {
+	return t.Column(name, "BIGINT")
+}
+
+// Boolean adds a BOOLEAN column
+func (t *Table) Boolean(name string) *Column {
+	return t.Column(name, "BOOLEAN")
+}
+
+// Decimal adds a NUMERIC(precision, scale) column
+func (t *Table) Decimal(name string, precision, scale int) *Column {
+	return t.Column(name, fmt.Sprintf("NUMERIC(%d,%d)", precision, scale))
+}
+
+// Float adds a DOUBLE PRECISION column
+func (t *Table) Float(name string) *Column {
+	return t.Column(name, "DOUBLE PRECISION")
+}
+
+// Date adds a DATE column
+func (t *Table) Date(name string) *Column {
+	return t.Column(name, "DATE")
+}
+
+// Timestamp adds a TIMESTAMP WITH TIME ZONE column
+func (t *Table) Timestamp(name string) *Column {
+	return t.Column(name, "TIMESTAMP WITH TIME ZONE")
+}
+
+// JSONB adds a JSONB column
+func (t *Table) JSONB(name string) *Column {
+	return t.Column(name, "JSONB")
+}
+
+// Enum adds a column of an enum type named <table>_<column>, created
+// before the column and dropped after it. Fresh drops it with the tables
+func (t *Table) Enum(name string, values ...string) *Column {
+	if len(values) == 0 {
+		t.fail(errors.NewValidationError("Invalid enum column", fmt.Sprintf("%s.%s needs at least one value", t.name, name)))
+	}
+	column := t.Column(name, "")
+	column.column.Enum = values
+	return column
+}
+
+// Timestamps adds created_at and updated_at columns defaulting to NOW()
+func (t *Table) Timestamps() {
+	t.Timestamp("created_at").Default("NOW()")
+	t.Timestamp("updated_at").Default("NOW()")
+}
+
+// SoftDeletes adds a nullable deleted_at column
+func (t *Table) SoftDeletes() {
+	t.Timestamp("deleted_at")
+}
+
+// Index adds an index named idx_<table>_<columns>
+func (t *Table) Index(columns ...string) {
+	t.addIndex(columns, false)
+}
+
+// Unique adds a unique index named uq_<table>_<columns>
+func (t *Table) Unique(columns ...string) {
+	t.addIndex(columns, true)
+}
+
+// RenameColumn renames a column; only available in Alter
+func (t *Table) RenameColumn(from, to string) {
+	if t.create {
+		t.fail(errors.NewValidationError("Invalid column rename", fmt.Sprintf("RenameColumn(%q, %q) on %s needs Alter, not Create", from, to, t.name)))
+		return
+	}
+	t.items = append(t.items, renameColumn{from: from, to: to})
+}
+
+func (t *Table) addIndex(columns []string, unique bool) {
+	if len(columns) == 0 {
+		t.fail(errors.NewValidationError("Invalid index", fmt.Sprintf("index on %s needs at least one column", t.name)))
+		return
+	}
+	t.items = append(t.items, ddl.Index{Columns: columns, Unique: unique})
+}
+
+// fail records the first error, returned by Compile
+func (t *Table) fail(err *errors.MigrationError) {
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+// Primary makes the column the primary key, or part of it if several
+// columns of a created table are primary
+func (c *Column) Primary() *Column {
+	c.column.Primary = true
+	return c
+}
+
+// NotNull adds NOT NULL; columns are nullable otherwise
+func (c *Column) NotNull() *Column {
+	c.column.NotNull = true
+	return c
+}
+
+// Unique adds UNIQUE
+func (c *Column) Unique() *Column {
+	c.column.Unique = true
+	return c
+}
+
+// Index adds an index named idx_<table>_<column>
+func (c *Column) Index() *Column {
+	c.column.Index = true
+	return c
+}
+
+// Default sets the default to a SQL expression, such as "0", "'draft'" or "NOW()"
+func (c *Column) Default(expr string) *Column {
+	c.column.Default = expr
+	return c
+}
+
+// References adds a foreign key named fk_<table>_<column> to table(column)
+func (c *Column) References(table, column string) *Column {
+	c.column.References = fmt.Sprintf("%s(%s)", table, column)
+	return c
+}
+
+// OnDelete sets the foreign key's ON DELETE action, such as "CASCADE" or "SET NULL"
+func (c *Column) OnDelete(action string) *Column {
+	c.column.OnDelete = action
+	return c
+}
+
+// specs returns the items of the table as JSON-encodable values
+func (t *Table) specs() []any {
+	specs := make([]any, 0, len(t.items))
+	for _, item := range t.items {
+		switch item := item.(type) {
+		case *Column:
+			specs = append(specs, map[string]any{"column": item.column})
+		case ddl.Index:
+			specs = append(specs, map[string]any{"index": item})
+		case renameColumn:
+			specs = append(specs, map[string]any{"rename_column": []string{item.from, item.to}})
+		}
+	}
+	return specs
+}
+
+// createStatements returns the statements creating and dropping the table
+// Dropping the table drops its indexes and constraints too
+func (t *Table) createStatements() (up, down []string) {
+	var columns []ddl.Column
+	var indexes []ddl.Index
+	for _, item := range t.items {
+		switch item := item.(type) {
+		case *Column:
+			columns = append(columns, item.column)
+			if item.column.Index {
+				indexes = append(indexes, ddl.Index{Columns: []string{item.column.Name}})
+			}
+		case ddl.Index:
+			indexes = append(indexes, item)
+		}
+	}
+
+	create := ddl.NewCreateTable(t.name, columns, indexes)
+	up = append(up, create.Types...)
+	up = append(up, create.Table)
+	up = append(up, create.Indexes...)
+	down = append([]string{create.Drop}, ddl.Reversed(create.DropTypes)...)
+	return up, down
+}
+
+// alterStatements returns the statements changing the table and undoing
+// the changes in reverse order
+func (t *Table) alterStatements() (up, down []string) {
+	for _, item := range t.items {
+		var itemUp, itemDown []string
+		switch item := item.(type) {
+		case *Column:
+			itemUp, itemDown = ddl.AddColumn(t.name, item.column)
+		case ddl.Index:
+			createIndex, dropIndex := ddl.CreateIndex(t.name, item)
+			itemUp, itemDown = []string{createIndex}, []string{dropIndex}
+		case renameColumn:
+			itemUp = []string{fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", t.name, item.from, item.to)}
+			itemDown = []string{fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", t.name, item.to, item.from)}
+		}
+		up = append(up, itemUp...)
+		down = append(itemDown, down...)
+	}
+	return up, down
+}
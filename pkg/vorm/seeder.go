@@ -0,0 +1,74 @@
+package vorm
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vorzela/vorm/internal/migration"
+)
+
+// Seeder is a Go function filling the database with data, registered with
+// WithSeeder. Go seeders run after the SQL seeders, in the order registered:
+//
+//	client, err := vorm.NewClient("", vorm.WithSeeder(vorm.Seeder{
+//		Name:         "demo_users",
+//		Environments: []string{"development"},
+//		Run: func(ctx context.Context, tx pgx.Tx) error {
+//			_, err := tx.Exec(ctx, `INSERT INTO users (email) VALUES ('demo@example.com')`)
+//			return err
+//		},
+//	}))
+type Seeder struct {
+	Name         string   // Unique among all seeders, used to select it
+	Environments []string // Empty runs in every environment
+	Once         bool     // Recorded in the database, and skipped on later runs
+
+	// Run seeds the database; returning an error rolls back tx
+	Run func(ctx context.Context, tx pgx.Tx) error
+}
+
+// WithSeeder registers a Go seeder
+func WithSeeder(s Seeder) Option {
+	return func(c *Client) {
+		c.seeders = append(c.seeders, &migration.Seeder{
+			Name:         s.Name,
+			Environments: s.Environments,
+			Once:         s.Once,
+			Func:         s.Run,
+		})
+	}
+}
+
+// WithSeedersDir loads SQL seeders from a directory on disk instead of the
+// seeders directory from the configuration
+func WithSeedersDir(dir string) Option {
+	return func(c *Client) {
+		c.seederSource = migration.NewDirSource(dir)
+	}
+}
+
+// WithSeedersFS loads SQL seeders from root inside fsys, such as an embed.FS
+func WithSeedersFS(fsys fs.FS, root string) Option {
+	return func(c *Client) {
+		c.seederSource = migration.NewFSSource(fsys, root)
+	}
+}
+
+// Seed runs the seeders enabled in the configured environment: SQL seeders
+// in filename order, then Go seeders. With names, only those seeders run
+func (c *Client) Seed(ctx context.Context, names ...string) error {
+	if err := c.startCommand("db:seed"); err != nil {
+		return err
+	}
+	return c.manager.RunSeeders(ctx, names)
+}
+
+// SeedRunner is implemented by Client; depend on it next to Migrator to
+// substitute a fake that seeds in tests
+type SeedRunner interface {
+	Seed(ctx context.Context, names ...string) error
+}
+
+// Ensure Client satisfies SeedRunner
+var _ SeedRunner = (*Client)(nil)
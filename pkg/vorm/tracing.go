@@ -0,0 +1,43 @@
+package vorm
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/vorzela/vorm/internal/tracing"
+)
+
+// WithTracerProvider creates the client's OpenTelemetry spans with provider
+//
+// Without this option a client uses the exporter from the tracing section
+// of the configuration when it is enabled, and the global provider otherwise,
+// so a service that already set one up sees its migrations in its traces
+//
+// Every operation, migration and statement gets a span. An operation called
+// with a context that carries no span continues the trace of the TRACEPARENT
+// environment variable, if set
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.tracerProvider = provider
+	}
+}
+
+// setupTracing gives the manager the caller's tracer provider, or one for
+// the configured exporter that the client owns and shuts down on Close
+func (c *Client) setupTracing() error {
+	if c.tracerProvider == nil && c.config.Tracing.Enabled {
+		provider, err := tracing.NewProvider(context.Background(), c.config, os.Stdout)
+		if err != nil {
+			return err
+		}
+		c.tracing = provider
+		c.tracerProvider = provider
+	}
+
+	if c.tracerProvider != nil {
+		c.manager.SetTracerProvider(c.tracerProvider)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package vorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/vorzela/vorm/internal/migration"
+)
+
+// Migrator is the set of migration operations offered by Client
+// Depend on it instead of *Client to substitute a fake in tests
+type Migrator interface {
+	CreateMigration(name string) (*MigrationInfo, error)
+	Migrate(ctx context.Context) error
+	MigrateSteps(ctx context.Context, steps int) error
+	Rollback(ctx context.Context) error
+	RollbackSteps(ctx context.Context, steps int) error
+	Reset(ctx context.Context) error
+	Fresh(ctx context.Context) error
+	Status(ctx context.Context) ([]Status, error)
+	List() ([]MigrationInfo, error)
+	History(ctx context.Context) ([]HistoryEntry, error)
+	PlanMigrate(ctx context.Context, steps int) (*Plan, error)
+	PlanRollback(ctx context.Context, steps int) (*Plan, error)
+	Close(ctx context.Context) error
+}
+
+// Ensure Client satisfies Migrator
+var _ Migrator = (*Client)(nil)
+
+// MigrationInfo describes a migration file
+type MigrationInfo struct {
+	Name     string `json:"name"`     // Migration name without timestamp, e.g. create_users_table
+	Filename string `json:"filename"` // File name including timestamp prefix
+	Path     string `json:"path"`     // Location of the file within its source
+	Checksum string `json:"checksum"` // SHA256 of the file content
+}
+
+// Status is the state of a single migration
+type Status struct {
+	Migration     MigrationInfo `json:"migration"`
+	Applied       bool          `json:"applied"`
+	AppliedAt     time.Time     `json:"applied_at"`      // Zero if not applied
+	Batch         int           `json:"batch,omitempty"` // Zero if not applied
+	ExecutionTime time.Duration `json:"execution_time,omitempty"`
+}
+
+// HistoryEntry is an applied migration as recorded in the migrations table
+type HistoryEntry struct {
+	Name          string        `json:"name"`
+	Batch         int           `json:"batch"`
+	AppliedAt     time.Time     `json:"applied_at"`
+	ExecutionTime time.Duration `json:"execution_time"`
+	Checksum      string        `json:"checksum"`
+	RunID         string        `json:"run_id,omitempty"` // Matches the run_id of the log events of that run
+}
+
+// Direction is the direction migrations are run in
+type Direction string
+
+const (
+	DirectionUp   Direction = "up"
+	DirectionDown Direction = "down"
+)
+
+// Plan lists the migrations an operation would run, in execution order
+type Plan struct {
+	Direction  Direction       `json:"direction"`
+	Batch      int             `json:"batch,omitempty"` // Batch new migrations are recorded in (up only)
+	Migrations []MigrationInfo `json:"migrations"`
+}
+
+// Empty returns true if the plan has nothing to run
+func (p *Plan) Empty() bool {
+	return len(p.Migrations) == 0
+}
+
+// newMigrationInfo converts an internal migration to its public form
+func newMigrationInfo(m *migration.Migration) MigrationInfo {
+	return MigrationInfo{
+		Name:     m.Name,
+		Filename: m.Filename,
+		Path:     m.Filepath,
+		Checksum: m.Checksum,
+	}
+}
+
+// newMigrationInfos converts a list of internal migrations
+func newMigrationInfos(migrations []*migration.Migration) []MigrationInfo {
+	infos := make([]MigrationInfo, 0, len(migrations))
+	for _, m := range migrations {
+		infos = append(infos, newMigrationInfo(m))
+	}
+	return infos
+}
+
+// newStatus converts an internal migration status
+func newStatus(s migration.MigrationStatus) Status {
+	status := Status{
+		Migration: newMigrationInfo(s.Migration),
+		Applied:   s.Executed,
+	}
+	if s.Executed {
+		status.AppliedAt = s.ExecutedAt
+		status.Batch = s.Batch
+		status.ExecutionTime = time.Duration(s.ExecutionTime) * time.Millisecond
+	}
+	return status
+}
+
+// newHistoryEntry converts an executed internal migration
+func newHistoryEntry(m *migration.Migration) HistoryEntry {
+	return HistoryEntry{
+		Name:          m.Name,
+		Batch:         m.Batch,
+		AppliedAt:     m.ExecutedAt,
+		ExecutionTime: time.Duration(m.ExecutionTime) * time.Millisecond,
+		Checksum:      m.Checksum,
+		RunID:         m.RunID,
+	}
+}
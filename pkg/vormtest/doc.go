@@ -0,0 +1,23 @@
+// Package vormtest gives integration tests an isolated PostgreSQL database
+// with every migration applied.
+//
+// The first call to New in a test binary builds a template database by
+// running the migrations once; each test then gets a clone made with
+// CREATE DATABASE ... TEMPLATE, which takes milliseconds, and the clone is
+// dropped when the test finishes:
+//
+//	func TestCreateUser(t *testing.T) {
+//		db := vormtest.New(t, vormtest.WithMigrationsDir("../../migrations"))
+//		db.AssertTable(t, "users")
+//
+//		conn := db.Conn(t)
+//		_, err := conn.Exec(context.Background(), `INSERT INTO users (email) VALUES ('a@example.com')`)
+//		...
+//	}
+//
+// The server comes from the usual vorm configuration: config/database.yaml
+// if the test's working directory has one, then VORM_DB_* variables and
+// VORM_DATABASE_URL. The user needs the CREATEDB privilege. Templates are
+// named after a hash of the migrations and reused by later test runs until
+// the migrations change.
+package vormtest
@@ -0,0 +1,83 @@
+package vormtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Tables may be schema-qualified, e.g. "billing.invoices"; unqualified names
+// are resolved through the search_path like in queries
+
+// AssertTable reports an error if table doesn't exist
+func (db *DB) AssertTable(tb testing.TB, table string) {
+	tb.Helper()
+
+	if !db.tableExists(tb, table) {
+		tb.Errorf("vormtest: table %s does not exist in %s", table, db.Name)
+	}
+}
+
+// AssertNoTable reports an error if table exists, e.g. after a rollback
+func (db *DB) AssertNoTable(tb testing.TB, table string) {
+	tb.Helper()
+
+	if db.tableExists(tb, table) {
+		tb.Errorf("vormtest: table %s exists in %s", table, db.Name)
+	}
+}
+
+// AssertColumn reports an error if table has no column named column, or if
+// dataType is set and differs from the column's type as PostgreSQL formats
+// it, e.g. "bigint", "character varying(255)" or "timestamp with time zone"
+func (db *DB) AssertColumn(tb testing.TB, table, column, dataType string) {
+	tb.Helper()
+
+	var actual string
+	err := db.Conn(tb).QueryRow(context.Background(), `
+		SELECT format_type(atttypid, atttypmod)
+		FROM pg_attribute
+		WHERE attrelid = to_regclass($1) AND attname = $2 AND attnum > 0 AND NOT attisdropped
+	`, table, column).Scan(&actual)
+	switch {
+	case err == pgx.ErrNoRows:
+		tb.Errorf("vormtest: column %s.%s does not exist in %s", table, column, db.Name)
+	case err != nil:
+		tb.Fatalf("vormtest: failed to look up column %s.%s: %v", table, column, err)
+	case dataType != "" && actual != dataType:
+		tb.Errorf("vormtest: column %s.%s has type %s, want %s", table, column, actual, dataType)
+	}
+}
+
+// AssertIndex reports an error if table has no index named index
+func (db *DB) AssertIndex(tb testing.TB, table, index string) {
+	tb.Helper()
+
+	var exists bool
+	err := db.Conn(tb).QueryRow(context.Background(), `
+		SELECT EXISTS (
+			SELECT 1
+			FROM pg_index i
+			JOIN pg_class c ON c.oid = i.indexrelid
+			WHERE i.indrelid = to_regclass($1) AND c.relname = $2
+		)
+	`, table, index).Scan(&exists)
+	if err != nil {
+		tb.Fatalf("vormtest: failed to look up index %s: %v", index, err)
+	}
+	if !exists {
+		tb.Errorf("vormtest: index %s on %s does not exist in %s", index, table, db.Name)
+	}
+}
+
+// tableExists returns true if table resolves to a relation
+func (db *DB) tableExists(tb testing.TB, table string) bool {
+	tb.Helper()
+
+	var exists bool
+	if err := db.Conn(tb).QueryRow(context.Background(), `SELECT to_regclass($1) IS NOT NULL`, table).Scan(&exists); err != nil {
+		tb.Fatalf("vormtest: failed to look up table %s: %v", table, err)
+	}
+	return exists
+}
@@ -0,0 +1,484 @@
+package vormtest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vorzela/vorm/internal/config"
+	"github.com/vorzela/vorm/internal/database"
+	"github.com/vorzela/vorm/internal/fixtures"
+	"github.com/vorzela/vorm/internal/logger"
+	"github.com/vorzela/vorm/internal/migration"
+	"github.com/vorzela/vorm/pkg/errors"
+	"github.com/vorzela/vorm/pkg/vorm/schema"
+)
+
+// maxPrefixName caps the part of the configured database name used in the
+// names of test databases; PostgreSQL truncates identifiers at 63 bytes
+const maxPrefixName = 20
+
+// Option configures New
+type Option func(*options)
+
+type options struct {
+	source           migration.Source
+	schemaMigrations []schemaMigration
+}
+
+// schemaMigration is a migration written with the schema builder
+type schemaMigration struct {
+	version string
+	ops     []schema.Operation
+}
+
+// WithMigrationsDir loads migrations from dir, e.g. "../../migrations" from
+// a package's test; without it the configured migration.directory is used
+func WithMigrationsDir(dir string) Option {
+	return func(o *options) {
+		o.source = migration.NewDirSource(dir)
+	}
+}
+
+// WithMigrationsFS loads migrations from root inside fsys, e.g. the embed.FS
+// the service migrates with
+func WithMigrationsFS(fsys fs.FS, root string) Option {
+	return func(o *options) {
+		o.source = migration.NewFSSource(fsys, root)
+	}
+}
+
+// WithSchemaMigration adds a migration written with the schema builder,
+// like vorm.WithSchemaMigration does for a client; pass the same
+// operations the service registers. Go migrations registered with
+// vorm.Register are always applied
+func WithSchemaMigration(version string, ops ...schema.Operation) Option {
+	return func(o *options) {
+		o.schemaMigrations = append(o.schemaMigrations, schemaMigration{version: version, ops: ops})
+	}
+}
+
+// migrations returns new migrations for the builder migrations and the
+// registered Go migrations, which are merged with those of the source
+func (o *options) migrations() ([]*migration.Migration, error) {
+	var migrations []*migration.Migration
+	for _, registered := range o.schemaMigrations {
+		m, err := migration.NewSchemaMigration(registered.version, registered.ops...)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, m)
+	}
+
+	goMigrations, err := migration.GoMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return append(migrations, goMigrations...), nil
+}
+
+// DB is a database owned by a single test
+type DB struct {
+	Name string // Database name
+
+	config  *config.Config
+	options options
+	conn    *pgx.Conn
+}
+
+// templates holds the template built for each set of migrations, so a test
+// binary builds each template at most once
+var templates sync.Map // template name -> *template
+
+type template struct {
+	once sync.Once
+	name string
+	err  error
+}
+
+// New creates a database with every migration applied, cloned from a
+// template, and drops it when tb and its subtests have finished
+func New(tb testing.TB, opts ...Option) *DB {
+	tb.Helper()
+
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		tb.Fatalf("vormtest: %v", err)
+	}
+	if o.source == nil {
+		o.source = migration.NewSourceFromConfig(cfg)
+	}
+
+	ctx := context.Background()
+	templateName, err := ensureTemplate(ctx, cfg, o)
+	if err != nil {
+		tb.Fatalf("vormtest: failed to build template database: %v", err)
+	}
+
+	name := databasePrefix(cfg) + randomSuffix()
+	if err := cloneDatabase(ctx, cfg, templateName, name); err != nil {
+		tb.Fatalf("vormtest: %v", err)
+	}
+
+	db := &DB{Name: name, config: withDatabase(cfg, name), options: o}
+	tb.Cleanup(func() {
+		if db.conn != nil {
+			db.conn.Close(ctx)
+		}
+		if err := database.NewCreator(db.config).DropDatabase(ctx); err != nil {
+			tb.Errorf("vormtest: failed to drop test database %s: %v", name, err)
+		}
+	})
+	return db
+}
+
+// DSN returns the connection string of the database
+func (db *DB) DSN() string {
+	return db.config.GetDSN()
+}
+
+// Conn returns a connection to the database, opened on first use and closed
+// when the test finishes
+func (db *DB) Conn(tb testing.TB) *pgx.Conn {
+	tb.Helper()
+
+	if db.conn == nil {
+		conn, err := pgx.Connect(context.Background(), db.DSN())
+		if err != nil {
+			tb.Fatalf("vormtest: failed to connect to %s: %v", db.Name, err)
+		}
+		db.conn = conn
+	}
+	return db.conn
+}
+
+// MigrateTo applies or rolls back migrations until version is the last one
+// applied. version is a migration version, such as
+// 2024_01_01_000000_create_users, or a unique prefix of one like its
+// timestamp; a name without the timestamp works too. An empty version rolls
+// back every migration
+func (db *DB) MigrateTo(tb testing.TB, version string) {
+	tb.Helper()
+
+	ctx := context.Background()
+	manager := db.manager(tb)
+	statuses, err := manager.GetMigrationStatus(ctx)
+	if err != nil {
+		tb.Fatalf("vormtest: failed to read migration status: %v", err)
+	}
+
+	target, err := findVersion(statuses, version) // Index of the last migration to keep applied
+	if err != nil {
+		tb.Fatalf("vormtest: %v", err)
+	}
+
+	applied := 0
+	for _, status := range statuses {
+		if status.Executed {
+			applied++
+		}
+	}
+
+	switch want := target + 1; {
+	case want < applied:
+		err = manager.RollbackSteps(ctx, applied-want)
+	case want > applied:
+		err = manager.RunMigrations(ctx, want-applied)
+	}
+	if err != nil {
+		tb.Fatalf("vormtest: failed to migrate to %q: %v", version, err)
+	}
+}
+
+// findVersion returns the index of the migration whose version starts with
+// version, or else whose name does; -1 if version is empty
+func findVersion(statuses []migration.MigrationStatus, version string) (int, error) {
+	if version == "" {
+		return -1, nil
+	}
+
+	keys := []func(m *migration.Migration) string{
+		func(m *migration.Migration) string { return strings.TrimSuffix(m.Filename, ".sql") },
+		func(m *migration.Migration) string { return m.Name },
+	}
+	for _, key := range keys {
+		target := -1
+		for i, status := range statuses {
+			if !strings.HasPrefix(key(status.Migration), version) {
+				continue
+			}
+			if target >= 0 {
+				return 0, fmt.Errorf("version %q matches both %s and %s", version, statuses[target].Migration.Filename, status.Migration.Filename)
+			}
+			target = i
+		}
+		if target >= 0 {
+			return target, nil
+		}
+	}
+	return 0, fmt.Errorf("no migration matches version %q", version)
+}
+
+// LoadFixtures inserts YAML, JSON and CSV fixtures into the database, see
+// the fixtures:load command. paths may be files, directories or globs
+func (db *DB) LoadFixtures(tb testing.TB, paths ...string) {
+	tb.Helper()
+
+	files, err := fixtures.ExpandPaths(paths)
+	if err != nil {
+		tb.Fatalf("vormtest: %v", err)
+	}
+	tables, err := fixtures.ReadFiles(files)
+	if err != nil {
+		tb.Fatalf("vormtest: %v", err)
+	}
+	if _, err := fixtures.NewLoader(db.Conn(tb), fixtures.Options{}).Load(context.Background(), tables); err != nil {
+		tb.Fatalf("vormtest: failed to load fixtures: %v", err)
+	}
+}
+
+// Applied returns the names of the applied migrations, oldest first
+func (db *DB) Applied(tb testing.TB) []string {
+	tb.Helper()
+
+	statuses, err := db.manager(tb).GetMigrationStatus(context.Background())
+	if err != nil {
+		tb.Fatalf("vormtest: failed to read migration status: %v", err)
+	}
+
+	var names []string
+	for _, status := range statuses {
+		if status.Executed {
+			names = append(names, status.Migration.Name)
+		}
+	}
+	return names
+}
+
+// manager returns a silent migration manager for the database
+func (db *DB) manager(tb testing.TB) *migration.Manager {
+	tb.Helper()
+
+	manager, err := newManager(db.config, db.options)
+	if err != nil {
+		tb.Fatalf("vormtest: %v", err)
+	}
+	return manager
+}
+
+// newManager returns a silent migration manager for the migrations of o
+func newManager(cfg *config.Config, o options) (*migration.Manager, error) {
+	manager, err := migration.NewManager(cfg, logger.NewNopLogger())
+	if err != nil {
+		return nil, err
+	}
+	manager.SetSource(o.source)
+
+	migrations, err := o.migrations()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range migrations {
+		manager.AddMigration(m)
+	}
+	return manager, nil
+}
+
+// baseConfig is the configuration loaded by the first New; config.Load
+// changes global viper state, so parallel tests must not call it
+var baseConfig struct {
+	once   sync.Once
+	config *config.Config
+	err    error
+}
+
+// loadConfig returns a copy of the vorm configuration for test databases
+func loadConfig() (*config.Config, error) {
+	baseConfig.once.Do(func() {
+		baseConfig.config, baseConfig.err = readConfig()
+	})
+	if baseConfig.err != nil {
+		return nil, baseConfig.err
+	}
+	cfg := *baseConfig.config
+	return &cfg, nil
+}
+
+// readConfig loads the vorm configuration for test databases
+// Hooks are dropped so building a template never notifies anyone, and
+// production environments are refused outright
+func readConfig() (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.IsProduction() {
+		return nil, errors.NewPermissionError("Refusing to create test databases",
+			fmt.Sprintf("environment %q is protected by the production policy", cfg.Environment))
+	}
+
+	cfg.Hooks = config.HooksConfig{}
+	cfg.Tracing.Enabled = false
+	return cfg, nil
+}
+
+// ensureTemplate returns the template database for the migrations of o,
+// building it unless this process or an earlier run already did
+func ensureTemplate(ctx context.Context, cfg *config.Config, o options) (string, error) {
+	generator := migration.NewGenerator(cfg)
+	generator.SetSource(o.source)
+	registered, err := o.migrations()
+	if err != nil {
+		return "", err
+	}
+	for _, m := range registered {
+		generator.AddMigration(m)
+	}
+	migrations, err := generator.LoadMigrations()
+	if err != nil {
+		return "", err
+	}
+	if len(migrations) == 0 {
+		return "", errors.NewValidationError("No migrations found", o.source.String())
+	}
+
+	name := databasePrefix(cfg) + "tmpl_" + templateKey(cfg, migrations)
+	entry, _ := templates.LoadOrStore(name, &template{name: name})
+	t := entry.(*template)
+	t.once.Do(func() {
+		t.err = buildTemplate(ctx, cfg, o, t.name)
+	})
+	return t.name, t.err
+}
+
+// templateKey hashes everything that shapes a template's schema,
+// including the builder and Go migrations; Go migrations are hashed by version
+func templateKey(cfg *config.Config, migrations []*migration.Migration) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n%s\n", database.SchemaVersion, cfg.Migration.Table)
+	for _, m := range migrations {
+		fmt.Fprintf(hash, "%s %s\n", m.Name, m.Checksum)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
+
+// buildTemplate migrates a scratch database and turns it into the template
+// name. An advisory lock serializes test binaries of different packages
+// building the same template concurrently
+func buildTemplate(ctx context.Context, cfg *config.Config, o options, name string) error {
+	conn, err := pgx.Connect(ctx, cfg.GetAdminDSN())
+	if err != nil {
+		return errors.NewConnectionError("Failed to connect to PostgreSQL server", err.Error()).WithCause(err)
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock(hashtext($1))`, name); err != nil {
+		return errors.NewMigrationError("Failed to lock template database", err.Error(), "").WithCause(err)
+	}
+	defer conn.Exec(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, name)
+
+	exists, err := database.NewCreator(withDatabase(cfg, name)).DatabaseExists(ctx)
+	if err != nil || exists {
+		return err
+	}
+
+	// Migrate under a scratch name, so an interrupted build never leaves a
+	// half-migrated template behind
+	scratch := withDatabase(cfg, name+"_build")
+	if err := database.NewCreator(scratch).DropDatabase(ctx); err != nil {
+		return err
+	}
+
+	manager, err := newManager(scratch, o)
+	if err != nil {
+		return err
+	}
+	if err := manager.RunMigrations(ctx, 0); err != nil {
+		return err
+	}
+
+	rename := fmt.Sprintf("ALTER DATABASE %s RENAME TO %s", pgx.Identifier{scratch.Database.Database}.Sanitize(), pgx.Identifier{name}.Sanitize())
+	if _, err := conn.Exec(ctx, rename); err != nil {
+		return errors.NewMigrationError("Failed to create template database", err.Error(), "").WithCause(err)
+	}
+
+	// The owner can clone the database either way; marking it as a template
+	// keeps stray connections out, but older servers reserve it to superusers
+	conn.Exec(ctx, fmt.Sprintf("ALTER DATABASE %s IS_TEMPLATE true ALLOW_CONNECTIONS false", pgx.Identifier{name}.Sanitize()))
+
+	dropStaleTemplates(ctx, conn, cfg, name)
+	return nil
+}
+
+// dropStaleTemplates drops the templates built for earlier versions of the
+// migrations; failures are ignored since another test binary may still use one
+func dropStaleTemplates(ctx context.Context, conn *pgx.Conn, cfg *config.Config, current string) {
+	prefix := databasePrefix(cfg) + "tmpl_"
+	rows, err := conn.Query(ctx, `SELECT datname FROM pg_database WHERE left(datname, length($1)) = $1 AND datname <> $2 AND datname NOT LIKE '%\_build'`,
+		prefix, current)
+	if err != nil {
+		return
+	}
+	stale, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return
+	}
+
+	for _, name := range stale {
+		conn.Exec(ctx, fmt.Sprintf("ALTER DATABASE %s IS_TEMPLATE false", pgx.Identifier{name}.Sanitize()))
+		conn.Exec(ctx, fmt.Sprintf("DROP DATABASE %s", pgx.Identifier{name}.Sanitize()))
+	}
+}
+
+// cloneDatabase creates name as a copy of templateName
+func cloneDatabase(ctx context.Context, cfg *config.Config, templateName, name string) error {
+	conn, err := pgx.Connect(ctx, cfg.GetAdminDSN())
+	if err != nil {
+		return errors.NewConnectionError("Failed to connect to PostgreSQL server", err.Error()).WithCause(err)
+	}
+	defer conn.Close(ctx)
+
+	sql := fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s OWNER %s",
+		pgx.Identifier{name}.Sanitize(),
+		pgx.Identifier{templateName}.Sanitize(),
+		pgx.Identifier{cfg.Database.Username}.Sanitize())
+	if _, err := conn.Exec(ctx, sql); err != nil {
+		return errors.NewMigrationError("Failed to create test database", err.Error(), "").WithCause(err)
+	}
+	return nil
+}
+
+// databasePrefix starts the name of every database vormtest creates for cfg
+func databasePrefix(cfg *config.Config) string {
+	base := strings.ToLower(cfg.Database.Database)
+	if len(base) > maxPrefixName {
+		base = base[:maxPrefixName]
+	}
+	return "vormtest_" + base + "_"
+}
+
+// randomSuffix returns a random name suffix for a test database
+func randomSuffix() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// withDatabase returns a copy of cfg connecting to database name
+func withDatabase(cfg *config.Config, name string) *config.Config {
+	c := *cfg
+	c.Database.Database = name
+	return &c
+}
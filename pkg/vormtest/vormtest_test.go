@@ -0,0 +1,44 @@
+package vormtest
+
+import (
+	"testing"
+
+	"github.com/vorzela/vorm/internal/migration"
+)
+
+func TestFindVersion(t *testing.T) {
+	statuses := []migration.MigrationStatus{
+		{Migration: &migration.Migration{Name: "create_users", Filename: "2024_01_01_000000_create_users.sql"}},
+		{Migration: &migration.Migration{Name: "create_posts", Filename: "2024_02_01_000000_create_posts"}},
+		{Migration: &migration.Migration{Name: "create_posts_index", Filename: "2024_02_01_000001_create_posts_index"}},
+	}
+
+	tests := []struct {
+		version string
+		want    int
+		wantErr bool
+	}{
+		{version: "", want: -1},
+		{version: "2024_01_01_000000", want: 0},
+		{version: "2024_01", want: 0},
+		{version: "2024_02_01_000000_create_posts", want: 1},
+		{version: "2024_02_01_000001", want: 2},
+		{version: "create_users", want: 0},
+		{version: "create_posts_", want: 2},
+		{version: "2024_02", wantErr: true},
+		{version: "create_posts", wantErr: true},
+		{version: "2025", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := findVersion(statuses, tt.version)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("findVersion(%q) = %d, want an error", tt.version, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("findVersion(%q) = %d, %v, want %d", tt.version, got, err, tt.want)
+		}
+	}
+}